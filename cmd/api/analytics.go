@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+const (
+	// analyticsMaxDays bounds the period covered by analytics.
+	analyticsMaxDays = 90
+	// analyticsPostsLimit is how many posts analytics break down.
+	analyticsPostsLimit = 50
+)
+
+// GetAnalytics godoc
+//
+//	@Summary		Fetches the analytics of the current user
+//	@Description	Fetches the daily views, reactions and comments on the posts of the current user and their new followers, with a breakdown of the most viewed posts. Days are UTC; views are counted once per user, post and day.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			days	query		int	false	"Number of days up to today, up to 90, 30 by default"
+//	@Success		200		{object}	store.Analytics
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/analytics [get]
+func (app *application) getAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	days := 30
+
+	if d := r.URL.Query().Get("days"); d != "" {
+		var err error
+		days, err = strconv.Atoi(d)
+		if err != nil {
+			app.badRequestErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	if days < 1 || days > analyticsMaxDays {
+		app.badRequestErrorResponse(w, r, errors.New("days must be between 1 and 90"))
+		return
+	}
+
+	user := app.getUserFromContext(r)
+	from := time.Now().UTC().AddDate(0, 0, 1-days)
+
+	analytics, err := app.store.Analytics.Get(r.Context(), user.ID, from, analyticsPostsLimit)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, analytics); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// recordViews counts a view by viewer of each of posts, except their own.
+// Views are buffered in Redis, so nothing is counted when it is disabled.
+// Failures are only logged: they must not fail the request.
+func (app *application) recordViews(ctx context.Context, viewer *store.User, posts ...*store.Post) {
+	if !app.config.redisCfg.enabled {
+		return
+	}
+
+	var ids []int64
+	for _, post := range posts {
+		if post.UserID != viewer.ID && post.Status == store.StatusPublished {
+			ids = append(ids, post.ID)
+		}
+	}
+
+	if err := app.cacheStorage.Views.Record(ctx, viewer.ID, ids, time.Now()); err != nil {
+		app.logger.Warnw("failed to record post views", "user_id", viewer.ID, "err", err)
+	}
+}
+
+// flushViews moves the views buffered in Redis to the database.
+func (app *application) flushViews(ctx context.Context) error {
+	views, ok, err := app.cacheStorage.Views.TakePending(ctx)
+	if err != nil || !ok {
+		return err
+	}
+
+	if err := app.store.Analytics.AddViews(ctx, views); err != nil {
+		return err
+	}
+
+	return app.cacheStorage.Views.AckPending(ctx)
+}
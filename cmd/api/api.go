@@ -14,6 +14,7 @@ import (
 	"github.com/DenysBahachuk/gopher_social/docs"
 	"github.com/DenysBahachuk/gopher_social/internal/auth"
 	"github.com/DenysBahachuk/gopher_social/internal/env"
+	"github.com/DenysBahachuk/gopher_social/internal/linkpreview"
 	"github.com/DenysBahachuk/gopher_social/internal/mailer"
 	"github.com/DenysBahachuk/gopher_social/internal/ratelimiter"
 	"github.com/DenysBahachuk/gopher_social/internal/store"
@@ -33,6 +34,7 @@ type application struct {
 	mailer        mailer.Client
 	authenticator auth.Authenticator
 	rateLimiter   ratelimiter.Limiter
+	linkPreviews  linkpreview.Fetcher
 }
 
 type dbConfig struct {
@@ -52,6 +54,15 @@ type config struct {
 	auth        authConfig
 	redisCfg    redisConfig
 	rateLimiter ratelimiter.Config
+	reactions   reactionsConfig
+	jobs        jobsConfig
+	trash       trashConfig
+	linkPreview linkPreviewConfig
+	comments    commentsConfig
+}
+
+type reactionsConfig struct {
+	kinds []string
 }
 
 type redisConfig struct {
@@ -102,9 +113,9 @@ func (app *application) mount() http.Handler {
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{env.GetString("CORS_ALLOWED_ORIGIN", "http://localhost:5174")},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match", "If-None-Match"},
+		ExposedHeaders:   []string{"Link", "ETag"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
@@ -138,9 +149,30 @@ func (app *application) mount() http.Handler {
 				r.Use(app.postContextMiddleware)
 
 				r.Get("/", app.getPostHandler)
-				r.Delete("/", app.сheckPostOwnership("admin", app.deletePostHandler))
-				r.Patch("/", app.сheckPostOwnership("moderator", app.updatePostHandler))
+				r.Delete("/", app.сheckPostOwnership("admin", app.requirePostIfMatch(app.deletePostHandler)))
+				r.Patch("/", app.сheckPostOwnership("moderator", app.requirePostIfMatch(app.updatePostHandler)))
+				r.Get("/comments", app.getCommentsHandler)
 				r.Post("/comments", app.createCommentsHandler)
+				r.Put("/poll/votes", app.votePollHandler)
+				r.Put("/pin", app.pinPostHandler)
+				r.Delete("/pin", app.unpinPostHandler)
+				r.Put("/content-warning", app.requireRole("moderator", app.setContentWarningHandler))
+				r.With(app.commentContextMiddleware).Patch("/comments/{commentId}", app.updateCommentHandler)
+				r.With(app.commentContextMiddleware).Delete("/comments/{commentId}", app.deleteCommentHandler)
+				r.With(app.commentContextMiddleware).Put("/comments/{commentId}/reactions", app.reactToCommentHandler)
+				r.With(app.commentContextMiddleware).Delete("/comments/{commentId}/reactions", app.unreactToCommentHandler)
+				r.With(app.commentContextMiddleware).Put("/comments/{commentId}/highlight", app.highlightCommentHandler)
+				r.With(app.commentContextMiddleware).Delete("/comments/{commentId}/highlight", app.unhighlightCommentHandler)
+				r.With(app.commentContextMiddleware).Put("/comments/{commentId}/hide", app.hideCommentHandler)
+				r.With(app.commentContextMiddleware).Delete("/comments/{commentId}/hide", app.unhideCommentHandler)
+				r.Put("/reactions", app.reactToPostHandler)
+				r.Delete("/reactions", app.unreactToPostHandler)
+				r.Put("/bookmarks", app.bookmarkPostHandler)
+				r.Delete("/bookmarks", app.unbookmarkPostHandler)
+				r.Put("/repost", app.repostHandler)
+				r.Delete("/repost", app.undoRepostHandler)
+				r.Get("/revisions", app.getPostRevisionsHandler)
+				r.Get("/revisions/diff", app.getPostRevisionsDiffHandler)
 			})
 		})
 
@@ -152,14 +184,70 @@ func (app *application) mount() http.Handler {
 				//	r.Use(app.userContextModdleware)
 
 				r.Get("/", app.getUserHandler)
+				r.Get("/posts", app.getUserPostsHandler)
 				r.Put("/follow", app.followUserHandler)
 				r.Put("/unfollow", app.unfollowUserHandler)
+				r.Put("/block", app.blockUserHandler)
+				r.Put("/unblock", app.unblockUserHandler)
 			})
 
 			r.Group(func(r chi.Router) {
 				r.Use(app.AuthTokenMiddleware)
 				r.Get("/feed", app.getUserFeedHandler)
 			})
+
+			r.Route("/me", func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware)
+				r.Get("/drafts", app.getDraftsHandler)
+				r.Get("/mentions", app.getMentionsHandler)
+				r.Get("/tags", app.getFollowedTagsHandler)
+				r.Get("/trash", app.getTrashHandler)
+				r.Put("/pins", app.reorderPinsHandler)
+				r.Get("/preferences", app.getPreferencesHandler)
+				r.Put("/preferences", app.updatePreferencesHandler)
+				r.Get("/analytics", app.getAnalyticsHandler)
+			})
+		})
+
+		r.Route("/bookmarks", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+			r.Get("/", app.getBookmarksHandler)
+
+			r.Route("/collections", func(r chi.Router) {
+				r.Get("/", app.getBookmarkCollectionsHandler)
+				r.Post("/", app.createBookmarkCollectionHandler)
+				r.Delete("/{collectionId}", app.deleteBookmarkCollectionHandler)
+			})
+		})
+
+		r.Route("/tags", func(r chi.Router) {
+			r.With(app.OptionalAuthTokenMiddleware).Get("/{tag}/posts", app.getTagPostsHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware)
+				r.Get("/aliases", app.getTagAliasesHandler)
+				r.Put("/aliases/{alias}", app.requireRole("moderator", app.createTagAliasHandler))
+				r.Delete("/aliases/{alias}", app.requireRole("moderator", app.deleteTagAliasHandler))
+				r.Put("/{tag}/follow", app.followTagHandler)
+				r.Delete("/{tag}/follow", app.unfollowTagHandler)
+			})
+		})
+
+		r.Route("/threads", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+			r.Get("/{postId}", app.getThreadHandler)
+		})
+
+		r.Route("/trending", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+			r.Get("/tags", app.getTrendingTagsHandler)
+			r.Get("/posts", app.getTrendingPostsHandler)
+		})
+
+		r.Route("/trash", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+			r.Post("/posts/{postId}/restore", app.restorePostHandler)
+			r.Post("/comments/{commentId}/restore", app.restoreCommentHandler)
 		})
 
 		//Public routes
@@ -185,6 +273,11 @@ func (app *application) run(mux http.Handler) error {
 		IdleTimeout:  time.Minute,
 	}
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	jobs := app.startJobs(jobsCtx)
+
 	shutdown := make(chan error)
 
 	go func() {
@@ -214,6 +307,9 @@ func (app *application) run(mux http.Handler) error {
 		return err
 	}
 
+	stopJobs()
+	jobs.Wait()
+
 	app.logger.Infow("server has stopped", "addr", app.config.addr, "env", app.config.env)
 
 	return nil
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type CreateBookmarkCollectionPayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// GetBookmarks godoc
+//
+//	@Summary		Fetches bookmarked posts
+//	@Description	Fetches the posts saved in a bookmark collection, most recently saved first
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			collection_id	query		int		false	"Collection ID, the default collection if omitted"
+//	@Param			limit			query		int		false	"Limit"
+//	@Param			cursor			query		string	false	"Cursor"
+//	@Success		200				{object}	store.PostsPage
+//	@Failure		400				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/bookmarks [get]
+func (app *application) getBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := parseCollectionID(r)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	ppq := store.PaginatedPostsQuery{
+		Limit: 20,
+	}
+
+	if err := ppq.Parse(r); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(ppq); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	page, err := app.store.Bookmarks.GetPosts(r.Context(), user.ID, collectionID, ppq)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetBookmarkCollections godoc
+//
+//	@Summary		Fetches bookmark collections
+//	@Description	Fetches the bookmark collections of the current user
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	[]store.BookmarkCollection
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/bookmarks/collections [get]
+func (app *application) getBookmarkCollectionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+
+	collections, err := app.store.Bookmarks.GetCollections(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, collections); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// CreateBookmarkCollection godoc
+//
+//	@Summary		Creates a bookmark collection
+//	@Description	Creates a named bookmark collection for the current user
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateBookmarkCollectionPayload	true	"Collection payload"
+//	@Success		201		{object}	store.BookmarkCollection
+//	@Failure		400		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/bookmarks/collections [post]
+func (app *application) createBookmarkCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	payload := CreateBookmarkCollectionPayload{}
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	collection := store.BookmarkCollection{
+		UserID: user.ID,
+		Name:   payload.Name,
+	}
+
+	if err := app.store.Bookmarks.CreateCollection(r.Context(), &collection); err != nil {
+		switch {
+		case errors.Is(err, store.ErrConflict):
+			app.conflictErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusCreated, collection); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// DeleteBookmarkCollection godoc
+//
+//	@Summary		Deletes a bookmark collection
+//	@Description	Deletes a bookmark collection and its bookmarks. The default collection cannot be deleted.
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int		true	"Collection ID"
+//	@Success		204	{string}	string	"Collection deleted"
+//	@Failure		400	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/bookmarks/collections/{id} [delete]
+func (app *application) deleteBookmarkCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := strconv.ParseInt(chi.URLParam(r, "collectionId"), 10, 64)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	if err := app.store.Bookmarks.DeleteCollection(r.Context(), collectionID, user.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// BookmarkPost godoc
+//
+//	@Summary		Bookmarks a post
+//	@Description	Saves a post in a bookmark collection
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"Post ID"
+//	@Param			collection_id	query		int		false	"Collection ID, the default collection if omitted"
+//	@Success		204				{string}	string	"Post bookmarked"
+//	@Failure		400				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/bookmarks [put]
+func (app *application) bookmarkPostHandler(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := parseCollectionID(r)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+	post := app.getPostFromCtx(r)
+
+	if err := app.store.Bookmarks.Add(r.Context(), user.ID, collectionID, post.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnbookmarkPost godoc
+//
+//	@Summary		Removes a bookmark
+//	@Description	Removes a post from a bookmark collection
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"Post ID"
+//	@Param			collection_id	query		int		false	"Collection ID, the default collection if omitted"
+//	@Success		204				{string}	string	"Bookmark removed"
+//	@Failure		400				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/bookmarks [delete]
+func (app *application) unbookmarkPostHandler(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := parseCollectionID(r)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+	post := app.getPostFromCtx(r)
+
+	if err := app.store.Bookmarks.Remove(r.Context(), user.ID, collectionID, post.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseCollectionID reads the optional collection_id query parameter. Zero
+// means the default collection.
+func parseCollectionID(r *http.Request) (int64, error) {
+	id := r.URL.Query().Get("collection_id")
+	if id == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(id, 10, 64)
+}
@@ -1,13 +1,107 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/go-chi/chi/v5"
 )
 
+type commentContext string
+
+const commentKey commentContext = "comment"
+
+// commentsPageSize is the default size of a page of comments, and the size
+// of the first page embedded in posts.
+const commentsPageSize = 20
+
+const (
+	// commentsDepth is how many levels of replies are listed by default.
+	commentsDepth = 2
+	// commentsRepliesSize is how many replies of each comment are listed by
+	// default.
+	commentsRepliesSize = 3
+)
+
+var errMaxCommentDepth = errors.New("comment is too deeply nested to reply to")
+
+type commentsConfig struct {
+	// maxDepth is how deep replies can nest, top-level comments being at
+	// depth 0.
+	maxDepth int
+}
+
 type createCommentsPayload struct {
 	Content string `json:"content" validate:"omitempty,max=100"`
+	// ParentCommentID makes the comment a reply to another comment of the
+	// post.
+	ParentCommentID *int64 `json:"parent_comment_id" validate:"omitempty,gte=1"`
+}
+
+type UpdateCommentPayload struct {
+	Content string `json:"content" validate:"required,max=100"`
+}
+
+// GetComments godoc
+//
+//	@Summary		Fetches the comments of a post
+//	@Description	Fetches the top-level comments of a post newest first, or the replies to a comment oldest first, with some levels of their replies, which are always oldest first. Top order ranks comments by reactions, decayed by age. The first page of top-level comments starts with the comment highlighted by the author of the post. In flat mode replies follow their parent, with their depth; in tree mode they are nested. Replies cut at the limit continue with the replies_next_cursor of their parent, and levels cut at the depth by fetching the replies of their comment. Deleted comments that have replies are returned as tombstones.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int		true	"Post ID"
+//	@Param			limit		query		int		false	"Limit, up to 50"
+//	@Param			cursor		query		string	false	"Cursor"
+//	@Param			parent_id	query		int		false	"Lists the replies to this comment"
+//	@Param			mode		query		string	false	"flat (default) or tree"
+//	@Param			sort		query		string	false	"top, new or old"
+//	@Param			depth		query		int		false	"Levels of replies, up to the maximum nesting depth"
+//	@Param			replies		query		int		false	"Replies per comment, up to 20"
+//	@Success		200			{object}	store.CommentsPage
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments [get]
+func (app *application) getCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	cq := app.defaultCommentsQuery()
+
+	if err := cq.Parse(r); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(cq); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if cq.Depth > app.config.comments.maxDepth {
+		app.badRequestErrorResponse(w, r, fmt.Errorf("depth must be at most %d", app.config.comments.maxDepth))
+		return
+	}
+
+	post := app.getPostFromCtx(r)
+	user := app.getUserFromContext(r)
+
+	page, err := app.store.Comments.GetByPostId(r.Context(), post.ID, user.ID, cq)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidCursor):
+			app.badRequestErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
 }
 
 func (app *application) createCommentsHandler(w http.ResponseWriter, r *http.Request) {
@@ -25,11 +119,55 @@ func (app *application) createCommentsHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	post := r.Context().Value(postKey).(*store.Post)
+	user := app.getUserFromContext(r)
+
+	allowed, err := app.store.Posts.CanComment(r.Context(), post.ID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
 
 	comments := store.Comment{
-		PostId:  post.ID,
-		UserId:  post.UserID,
-		Content: commentsPayload.Content,
+		PostId:   post.ID,
+		UserId:   user.ID,
+		Content:  commentsPayload.Content,
+		Entities: store.ParseEntities(commentsPayload.Content),
+	}
+
+	if commentsPayload.ParentCommentID != nil {
+		parent, err := app.store.Comments.GetById(r.Context(), *commentsPayload.ParentCommentID)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.badRequestErrorResponse(w, r, errors.New("parent comment not found"))
+			default:
+				app.internalServerErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		if parent.PostId != post.ID || !commentShownTo(parent, post, user) {
+			app.badRequestErrorResponse(w, r, errors.New("parent comment not found"))
+			return
+		}
+
+		if parent.Depth >= app.config.comments.maxDepth {
+			app.badRequestErrorResponse(w, r, errMaxCommentDepth)
+			return
+		}
+
+		comments.ParentCommentID = &parent.ID
+		comments.Depth = parent.Depth + 1
 	}
 
 	err = app.store.Comments.Create(r.Context(), &comments)
@@ -44,3 +182,301 @@ func (app *application) createCommentsHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 }
+
+// UpdateComment godoc
+//
+//	@Summary		Updates a comment
+//	@Description	Changes the content of a comment of the current user, which is then marked as edited
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int						true	"Post ID"
+//	@Param			commentId	path		int						true	"Comment ID"
+//	@Param			payload		body		UpdateCommentPayload	true	"Comment payload"
+//	@Success		200			{object}	store.Comment
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentId} [patch]
+func (app *application) updateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateCommentPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	comment := app.getCommentFromCtx(r)
+	user := app.getUserFromContext(r)
+
+	if comment.UserId != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	comment.Content = payload.Content
+	comment.Entities = store.ParseEntities(payload.Content)
+
+	if err := app.store.Comments.Update(r.Context(), comment); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, comment); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// DeleteComment godoc
+//
+//	@Summary		Deletes a comment
+//	@Description	Moves a comment to the trash of its author, from where it can be restored until it is purged. Comments can be deleted by their author, the author of the post and moderators.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int		true	"Post ID"
+//	@Param			commentId	path		int		true	"Comment ID"
+//	@Success		204			{string}	string	"Comment deleted"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentId} [delete]
+func (app *application) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment := app.getCommentFromCtx(r)
+	post := app.getPostFromCtx(r)
+	user := app.getUserFromContext(r)
+
+	if comment.UserId != user.ID && post.UserID != user.ID {
+		allowed, err := app.checkRolePrecedence(r.Context(), "moderator", user)
+		if err != nil {
+			app.internalServerErrorResponse(w, r, err)
+			return
+		}
+
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+	}
+
+	if err := app.store.Comments.Delete(r.Context(), comment.ID, user.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HighlightComment godoc
+//
+//	@Summary		Highlights a comment
+//	@Description	Highlights a top-level comment on a post of the current user, replacing any other. The highlighted comment is listed first.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int		true	"Post ID"
+//	@Param			commentId	path		int		true	"Comment ID"
+//	@Success		204			{string}	string	"Comment highlighted"
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentId}/highlight [put]
+func (app *application) highlightCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment := app.getCommentFromCtx(r)
+	post := app.getPostFromCtx(r)
+	user := app.getUserFromContext(r)
+
+	if post.UserID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if comment.ParentCommentID != nil {
+		app.badRequestErrorResponse(w, r, errors.New("only top-level comments can be highlighted"))
+		return
+	}
+
+	if err := app.store.Comments.Highlight(r.Context(), post.ID, comment.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnhighlightComment godoc
+//
+//	@Summary		Removes the highlight of a comment
+//	@Description	Removes the highlight of a comment on a post of the current user
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int		true	"Post ID"
+//	@Param			commentId	path		int		true	"Comment ID"
+//	@Success		204			{string}	string	"Highlight removed"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentId}/highlight [delete]
+func (app *application) unhighlightCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment := app.getCommentFromCtx(r)
+	post := app.getPostFromCtx(r)
+	user := app.getUserFromContext(r)
+
+	if post.UserID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Comments.Unhighlight(r.Context(), post.ID, comment.ID); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HideComment godoc
+//
+//	@Summary		Hides a comment
+//	@Description	Hides a comment on a post of the current user from everyone but its author, without deleting it. Hidden comments that have replies are shown to others as tombstones.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int		true	"Post ID"
+//	@Param			commentId	path		int		true	"Comment ID"
+//	@Success		204			{string}	string	"Comment hidden"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentId}/hide [put]
+func (app *application) hideCommentHandler(w http.ResponseWriter, r *http.Request) {
+	app.setCommentHidden(w, r, true)
+}
+
+// UnhideComment godoc
+//
+//	@Summary		Unhides a comment
+//	@Description	Shows a hidden comment on a post of the current user to everyone again
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int		true	"Post ID"
+//	@Param			commentId	path		int		true	"Comment ID"
+//	@Success		204			{string}	string	"Comment unhidden"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentId}/hide [delete]
+func (app *application) unhideCommentHandler(w http.ResponseWriter, r *http.Request) {
+	app.setCommentHidden(w, r, false)
+}
+
+func (app *application) setCommentHidden(w http.ResponseWriter, r *http.Request, hidden bool) {
+	comment := app.getCommentFromCtx(r)
+	post := app.getPostFromCtx(r)
+	user := app.getUserFromContext(r)
+
+	if post.UserID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Comments.SetHidden(r.Context(), comment.ID, hidden); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// commentShownTo reports whether comment is shown to user: hidden comments
+// are only shown to their author and to the author of post.
+func commentShownTo(comment *store.Comment, post *store.Post, user *store.User) bool {
+	return !comment.Hidden || comment.UserId == user.ID || post.UserID == user.ID
+}
+
+// commentContextMiddleware loads the comment of a /posts/{postId}/comments
+// route. It must run after postContextMiddleware, and answers 404 for
+// comments that belong to another post or are hidden from the user.
+func (app *application) commentContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		commentID, err := strconv.ParseInt(chi.URLParam(r, "commentId"), 10, 64)
+		if err != nil {
+			app.badRequestErrorResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		comment, err := app.store.Comments.GetById(ctx, commentID)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundErrorResponse(w, r, err)
+			default:
+				app.internalServerErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		post := app.getPostFromCtx(r)
+
+		if comment.PostId != post.ID || !commentShownTo(comment, post, app.getUserFromContext(r)) {
+			app.notFoundErrorResponse(w, r, store.ErrNotFound)
+			return
+		}
+
+		ctx = context.WithValue(ctx, commentKey, comment)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// defaultCommentsQuery returns the query of the first page of comments of a
+// post.
+func (app *application) defaultCommentsQuery() store.CommentsQuery {
+	return store.CommentsQuery{
+		Limit:   commentsPageSize,
+		Mode:    store.CommentsFlat,
+		Depth:   min(commentsDepth, app.config.comments.maxDepth),
+		Replies: commentsRepliesSize,
+	}
+}
+
+func (app *application) getCommentFromCtx(r *http.Request) *store.Comment {
+	return r.Context().Value(commentKey).(*store.Comment)
+}
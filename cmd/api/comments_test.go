@@ -0,0 +1,162 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUpdateCommentHandler(t *testing.T) {
+	post := &store.Post{ID: 10, UserID: 1}
+	comment := &store.Comment{ID: 20, PostId: post.ID, UserId: 2}
+
+	tests := []struct {
+		name     string
+		user     *store.User
+		expected int
+	}{
+		{"should let the author edit", &store.User{ID: 2}, http.StatusOK},
+		{"should not let the author of the post edit", &store.User{ID: 1}, http.StatusForbidden},
+		{"should not let moderators edit", &store.User{ID: 3, Role: store.Role{Name: "moderator", Level: 2}}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, config{})
+
+			comments := app.store.Comments.(*store.MockCommentsStore)
+			comments.On("Update", mock.Anything).Return(nil)
+
+			comment := *comment
+			req := newRequestAs(t, tt.user, http.MethodPatch, "/v1/posts/10/comments/20", strings.NewReader(`{"content": "edited"}`))
+			rr := executeRequest(http.HandlerFunc(app.updateCommentHandler), withComment(withPost(req, post), &comment))
+
+			checkresponseCode(t, tt.expected, rr.Code)
+
+			if tt.expected == http.StatusForbidden {
+				comments.AssertNumberOfCalls(t, "Update", 0)
+			}
+		})
+	}
+}
+
+func TestDeleteCommentHandler(t *testing.T) {
+	post := &store.Post{ID: 10, UserID: 1}
+	comment := &store.Comment{ID: 20, PostId: post.ID, UserId: 2}
+
+	tests := []struct {
+		name     string
+		user     *store.User
+		expected int
+	}{
+		{"should let the author delete", &store.User{ID: 2, Role: store.Role{Name: "user", Level: 1}}, http.StatusNoContent},
+		{"should let the author of the post delete", &store.User{ID: 1, Role: store.Role{Name: "user", Level: 1}}, http.StatusNoContent},
+		{"should let moderators delete", &store.User{ID: 3, Role: store.Role{Name: "moderator", Level: 2}}, http.StatusNoContent},
+		{"should not let others delete", &store.User{ID: 4, Role: store.Role{Name: "user", Level: 1}}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, config{})
+
+			comments := app.store.Comments.(*store.MockCommentsStore)
+			comments.On("Delete", comment.ID, tt.user.ID).Return(nil)
+
+			req := newRequestAs(t, tt.user, http.MethodDelete, "/v1/posts/10/comments/20", nil)
+			rr := executeRequest(http.HandlerFunc(app.deleteCommentHandler), withComment(withPost(req, post), comment))
+
+			checkresponseCode(t, tt.expected, rr.Code)
+
+			if tt.expected == http.StatusForbidden {
+				comments.AssertNumberOfCalls(t, "Delete", 0)
+			}
+		})
+	}
+}
+
+func TestGetCommentsHandler(t *testing.T) {
+	user := &store.User{ID: 1}
+	post := &store.Post{ID: 10, UserID: 2}
+
+	t.Run("should page with the cursor", func(t *testing.T) {
+		app := newTestApplication(t, config{comments: commentsConfig{maxDepth: 3}})
+
+		cursor := store.Cursor{CreatedAt: "2024-01-01T00:00:00Z", ID: 5}
+
+		cq := app.defaultCommentsQuery()
+		cq.Limit = 5
+		cq.Cursor = &cursor
+
+		comments := app.store.Comments.(*store.MockCommentsStore)
+		comments.On("GetByPostId", post.ID, user.ID, cq).
+			Return(&store.CommentsPage{Comments: []*store.Comment{}}, nil)
+
+		req := newRequestAs(t, user, http.MethodGet, "/v1/posts/10/comments?limit=5&cursor="+cursor.Encode(), nil)
+		rr := executeRequest(http.HandlerFunc(app.getCommentsHandler), withPost(req, post))
+
+		checkresponseCode(t, http.StatusOK, rr.Code)
+		comments.AssertExpectations(t)
+	})
+
+	t.Run("should reject malformed cursors", func(t *testing.T) {
+		app := newTestApplication(t, config{comments: commentsConfig{maxDepth: 3}})
+
+		req := newRequestAs(t, user, http.MethodGet, "/v1/posts/10/comments?cursor=nope", nil)
+		rr := executeRequest(http.HandlerFunc(app.getCommentsHandler), withPost(req, post))
+
+		checkresponseCode(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should not go deeper than the maximum depth", func(t *testing.T) {
+		app := newTestApplication(t, config{comments: commentsConfig{maxDepth: 2}})
+
+		req := newRequestAs(t, user, http.MethodGet, "/v1/posts/10/comments?mode=tree&depth=3", nil)
+		rr := executeRequest(http.HandlerFunc(app.getCommentsHandler), withPost(req, post))
+
+		checkresponseCode(t, http.StatusBadRequest, rr.Code)
+		app.store.Comments.(*store.MockCommentsStore).AssertNumberOfCalls(t, "GetByPostId", 0)
+	})
+}
+
+func TestCreateCommentReplyHandler(t *testing.T) {
+	user := &store.User{ID: 1}
+	post := &store.Post{ID: 10, UserID: 2}
+
+	tests := []struct {
+		name     string
+		parent   *store.Comment
+		expected int
+	}{
+		{"should reply below the maximum depth", &store.Comment{ID: 20, PostId: post.ID, Depth: 1}, http.StatusCreated},
+		{"should not reply at the maximum depth", &store.Comment{ID: 20, PostId: post.ID, Depth: 2}, http.StatusBadRequest},
+		{"should not reply to comments of other posts", &store.Comment{ID: 20, PostId: 11}, http.StatusBadRequest},
+		{"should not reply to hidden comments", &store.Comment{ID: 20, PostId: post.ID, UserId: 3, Hidden: true}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, config{comments: commentsConfig{maxDepth: 2}})
+
+			app.store.Posts.(*store.MockPostsStore).On("CanComment", post.ID, user.ID).Return(true, nil)
+
+			comments := app.store.Comments.(*store.MockCommentsStore)
+			comments.On("GetById", tt.parent.ID).Return(tt.parent, nil)
+			comments.On("Create", mock.MatchedBy(func(c *store.Comment) bool {
+				return *c.ParentCommentID == tt.parent.ID && c.Depth == tt.parent.Depth+1
+			})).Return(nil)
+
+			body := `{"content": "reply", "parent_comment_id": 20}`
+			req := withPost(newRequestAs(t, user, http.MethodPost, "/v1/posts/10/comments", strings.NewReader(body)), post)
+			rr := executeRequest(http.HandlerFunc(app.createCommentsHandler), req)
+
+			checkresponseCode(t, tt.expected, rr.Code)
+
+			if tt.expected == http.StatusBadRequest {
+				comments.AssertNumberOfCalls(t, "Create", 0)
+			}
+		})
+	}
+}
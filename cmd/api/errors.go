@@ -55,3 +55,15 @@ func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http
 
 	writeErrorJSON(w, http.StatusTooManyRequests, "rate limit exceeded, retry after: "+retryAfter)
 }
+
+func (app *application) preconditionFailedResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Warnw("precondition failed", "method", r.Method, "path", r.URL.Path, "err", err)
+
+	writeErrorJSON(w, http.StatusPreconditionFailed, err.Error())
+}
+
+func (app *application) preconditionRequiredResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Warnw("precondition required", "method", r.Method, "path", r.URL.Path, "err", err)
+
+	writeErrorJSON(w, http.StatusPreconditionRequired, err.Error())
+}
@@ -1,26 +1,41 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/DenysBahachuk/gopher_social/internal/store"
 )
 
+// feedOffsetDeprecation is the Deprecation header (RFC 9745) of feed pages
+// fetched by offset: they have been deprecated since cursors were added.
+var feedOffsetDeprecation = fmt.Sprintf("@%d", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC).Unix())
+
+// feedDeprecationLink points clients of offset pages to the docs of cursor
+// pages.
+const feedDeprecationLink = `</v1/swagger/index.html#/feed/get_users_feed>; rel="deprecation"`
+
 // getUserFeedHandler godoc
 //
 //	@Summary		Fetches the user feed
-//	@Description	Fetches the user feed
+//	@Description	Fetches the posts of followed users, posts reposted by them and posts with followed tags. Pages are fetched with a cursor, or with page=cursor for the first one, and come as an object linked by next_cursor and prev_cursor, also given as Link headers. The prev_cursor of the first page fetches the posts that arrived since. Without them, pages are fetched by offset and come as a bare list of posts, with Deprecation and Link headers pointing to cursor pages.
 //	@Tags			feed
 //	@Accept			json
 //	@Produce		json
 //	@Param			since	query		string	false	"Since"
 //	@Param			until	query		string	false	"Until"
 //	@Param			limit	query		int		false	"Limit"
-//	@Param			offset	query		int		false	"Offset"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Param			page	query		string	false	"cursor, to get the first page with cursors"
+//	@Param			offset	query		int		false	"Offset (deprecated)"
 //	@Param			sort	query		string	false	"Sort"
 //	@Param			tags	query		string	false	"Tags"
 //	@Param			search	query		string	false	"Search"
-//	@Success		200		{object}	[]store.PostForFeed
+//	@Success		200		{object}	store.FeedPage
 //	@Failure		400		{object}	error
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
@@ -43,15 +58,70 @@ func (app *application) getUserFeedHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	feed, err := app.store.Posts.GetUserFeed(r.Context(), int64(130), pfq)
+	// Clients opt into cursor pages, so that those written for offset
+	// pages keep getting the shape they expect.
+	cursors := pfq.Cursor != nil || r.URL.Query().Get("page") == "cursor"
+	if cursors && r.URL.Query().Has("offset") {
+		app.badRequestErrorResponse(w, r, errors.New("cursor and offset cannot be used together"))
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	page, err := app.store.Posts.GetUserFeed(r.Context(), user.ID, pfq)
 	if err != nil {
 		app.internalServerErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeResponse(w, http.StatusOK, feed)
+	posts := make([]*store.Post, len(page.Posts))
+	for i, item := range page.Posts {
+		posts[i] = &item.Post
+	}
+	app.recordViews(r.Context(), user, posts...)
+
+	links := paginationLinks(r, page.NextCursor, page.PrevCursor)
+
+	var body any = page
+	if !cursors {
+		body = page.Posts
+
+		w.Header().Set("Deprecation", feedOffsetDeprecation)
+		if links != "" {
+			links += ", "
+		}
+		links += feedDeprecationLink
+	}
+
+	if links != "" {
+		w.Header().Set("Link", links)
+	}
+
+	err = app.writeResponse(w, http.StatusOK, body)
 	if err != nil {
 		app.internalServerErrorResponse(w, r, err)
 		return
 	}
 }
+
+// paginationLinks returns an RFC 8288 Link header value pointing at the
+// pages of next and prev, which are cursors. The other query parameters of
+// r are kept, except the deprecated offset.
+func paginationLinks(r *http.Request, next, prev string) string {
+	var links []string
+
+	for _, link := range []struct{ rel, cursor string }{{"next", next}, {"prev", prev}} {
+		if link.cursor == "" {
+			continue
+		}
+
+		q := r.URL.Query()
+		q.Del("offset")
+		q.Set("cursor", link.cursor)
+
+		u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), link.rel))
+	}
+
+	return strings.Join(links, ", ")
+}
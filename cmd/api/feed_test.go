@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetUserFeedHandler(t *testing.T) {
+	user := &store.User{ID: 1}
+	page := &store.FeedPage{
+		Posts:      []*store.PostForFeed{{Post: store.Post{ID: 7, UserID: 2}}},
+		NextCursor: "abc",
+	}
+
+	t.Run("should keep offset pages a list of posts and mark them deprecated", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		posts := app.store.Posts.(*store.MockPostsStore)
+		posts.On("GetUserFeed", user.ID, mock.Anything).Return(page, nil)
+
+		rr := executeRequest(
+			http.HandlerFunc(app.getUserFeedHandler),
+			newRequestAs(t, user, http.MethodGet, "/v1/users/feed", nil),
+		)
+
+		checkresponseCode(t, http.StatusOK, rr.Code)
+
+		var body struct {
+			Data []store.PostForFeed `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("expected a list of posts: %v", err)
+		}
+		if len(body.Data) != 1 || body.Data[0].ID != 7 {
+			t.Errorf("expected post 7; got %v", body.Data)
+		}
+
+		if got := rr.Header().Get("Deprecation"); got != feedOffsetDeprecation {
+			t.Errorf("expected Deprecation %s; got %q", feedOffsetDeprecation, got)
+		}
+
+		link := rr.Header().Get("Link")
+		if !strings.Contains(link, `</v1/users/feed?cursor=abc>; rel="next"`) || !strings.Contains(link, `rel="deprecation"`) {
+			t.Errorf("expected links to the next cursor page and the deprecation; got %s", link)
+		}
+	})
+
+	for _, target := range []string{"/v1/users/feed?page=cursor", "/v1/users/feed?cursor=" + store.Cursor{CreatedAt: "2024-01-01T00:00:00Z", ID: 5}.Encode()} {
+		t.Run("should return a cursor page for "+target, func(t *testing.T) {
+			app := newTestApplication(t, config{})
+
+			posts := app.store.Posts.(*store.MockPostsStore)
+			posts.On("GetUserFeed", user.ID, mock.Anything).Return(page, nil)
+
+			rr := executeRequest(
+				http.HandlerFunc(app.getUserFeedHandler),
+				newRequestAs(t, user, http.MethodGet, target, nil),
+			)
+
+			checkresponseCode(t, http.StatusOK, rr.Code)
+
+			var body struct {
+				Data store.FeedPage `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("expected a feed page: %v", err)
+			}
+			if body.Data.NextCursor != "abc" {
+				t.Errorf("expected next cursor abc; got %q", body.Data.NextCursor)
+			}
+
+			if got := rr.Header().Get("Deprecation"); got != "" {
+				t.Errorf("expected no Deprecation; got %s", got)
+			}
+		})
+	}
+
+	t.Run("should reject cursors with an offset", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		rr := executeRequest(
+			http.HandlerFunc(app.getUserFeedHandler),
+			newRequestAs(t, user, http.MethodGet, "/v1/users/feed?page=cursor&offset=20", nil),
+		)
+
+		checkresponseCode(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestPaginationLinks(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/v1/users/feed?limit=5&offset=10&tags=go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should link both pages and drop the offset", func(t *testing.T) {
+		got := paginationLinks(req, "abc", "xyz")
+		want := `</v1/users/feed?cursor=abc&limit=5&tags=go>; rel="next", </v1/users/feed?cursor=xyz&limit=5&tags=go>; rel="prev"`
+
+		if got != want {
+			t.Errorf("expected %s; got %s", want, got)
+		}
+	})
+
+	t.Run("should leave out missing pages", func(t *testing.T) {
+		if got := paginationLinks(req, "", ""); got != "" {
+			t.Errorf("expected no links; got %s", got)
+		}
+	})
+}
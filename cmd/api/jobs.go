@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const publishBatchSize = 100
+
+type jobsConfig struct {
+	enabled             bool
+	publishInterval     time.Duration
+	purgeInterval       time.Duration
+	trendingInterval    time.Duration
+	linkPreviewInterval time.Duration
+	viewsFlushInterval  time.Duration
+}
+
+// startJobs launches the background jobs of the API process. They stop when
+// ctx is cancelled; wait on the returned group to let them finish.
+func (app *application) startJobs(ctx context.Context) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+
+	if !app.config.jobs.enabled {
+		return wg
+	}
+
+	app.runJob(ctx, wg, "publish scheduled posts", app.config.jobs.publishInterval, app.publishScheduledPosts)
+	app.runJob(ctx, wg, "purge trash", app.config.jobs.purgeInterval, app.purgeTrash)
+	app.runJob(ctx, wg, "refresh trending", app.config.jobs.trendingInterval, app.refreshTrending)
+	app.runJob(ctx, wg, "fetch link previews", app.config.jobs.linkPreviewInterval, app.fetchLinkPreviews)
+
+	// Views are only buffered when Redis is enabled.
+	if app.config.redisCfg.enabled {
+		app.runJob(ctx, wg, "flush post views", app.config.jobs.viewsFlushInterval, app.flushViews)
+	}
+
+	return wg
+}
+
+// runJob calls job every interval until ctx is cancelled. A failed run is
+// logged and retried on the next tick.
+func (app *application) runJob(ctx context.Context, wg *sync.WaitGroup, name string, interval time.Duration, job func(context.Context) error) {
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := job(ctx); err != nil {
+					app.logger.Errorw("background job failed", "job", name, "err", err)
+				}
+			}
+		}
+	}()
+}
+
+// publishScheduledPosts publishes every scheduled post that is due. It is
+// safe to run on several replicas at once, see PostsStore.PublishDue.
+func (app *application) publishScheduledPosts(ctx context.Context) error {
+	for {
+		ids, err := app.store.Posts.PublishDue(ctx, publishBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(ids) > 0 {
+			app.logger.Infow("published scheduled posts", "ids", ids)
+		}
+
+		if len(ids) < publishBatchSize {
+			return nil
+		}
+	}
+}
@@ -36,6 +36,21 @@ func readJSON(w http.ResponseWriter, r *http.Request, data any) error {
 	return decoder.Decode(data)
 }
 
+// encodeResponse encodes data the way writeResponse sends it, for handlers
+// that need the body before writing it.
+func encodeResponse(data any) ([]byte, error) {
+	type envelope struct {
+		Data any `json:"data"`
+	}
+
+	body, err := json.Marshal(&envelope{Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(body, '\n'), nil
+}
+
 func writeErrorJSON(w http.ResponseWriter, status int, message string) error {
 	type envelope struct {
 		Error string `json:"error"`
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/DenysBahachuk/gopher_social/internal/linkpreview"
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+// linkPreviewBatchSize bounds the pages fetched per run of the link preview
+// job, as each may take up to the fetcher timeout.
+const linkPreviewBatchSize = 20
+
+type linkPreviewConfig struct {
+	fetcher linkpreview.Config
+	// maxAge is how long a preview, or a failed fetch, is kept before the
+	// page is fetched again.
+	maxAge time.Duration
+}
+
+// linkURL returns the URL whose preview is attached to a post with content,
+// or nil.
+func linkURL(content string) *string {
+	url := linkpreview.FirstURL(content)
+	if url == "" {
+		return nil
+	}
+
+	return &url
+}
+
+// fetchLinkPreviews fetches the previews of the URLs linked from posts that
+// have none yet, or a stale one. Pages that can't be previewed are recorded
+// as failed, so they are not fetched again until they go stale.
+func (app *application) fetchLinkPreviews(ctx context.Context) error {
+	staleBefore := time.Now().Add(-app.config.linkPreview.maxAge)
+
+	urls, err := app.store.LinkPreviews.GetPending(ctx, staleBefore, linkPreviewBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, url := range urls {
+		preview, err := app.linkPreviews.Fetch(ctx, url)
+		if err != nil {
+			app.logger.Infow("link preview failed", "url", url, "err", err)
+
+			if err := app.store.LinkPreviews.SaveFailed(ctx, url); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := app.store.LinkPreviews.Save(ctx, &store.LinkPreview{
+			URL:         url,
+			Title:       preview.Title,
+			Description: preview.Description,
+			Image:       preview.Image,
+			SiteName:    preview.SiteName,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/DenysBahachuk/gopher_social/internal/auth"
 	"github.com/DenysBahachuk/gopher_social/internal/db"
 	"github.com/DenysBahachuk/gopher_social/internal/env"
+	"github.com/DenysBahachuk/gopher_social/internal/linkpreview"
 	"github.com/DenysBahachuk/gopher_social/internal/mailer"
 	"github.com/DenysBahachuk/gopher_social/internal/ratelimiter"
 	"github.com/DenysBahachuk/gopher_social/internal/store"
@@ -89,6 +90,32 @@ func main() {
 			TimeFrame:            time.Second * 5,
 			Enabled:              env.GetBool("RATELIMITER_ENABLED", true),
 		},
+		reactions: reactionsConfig{
+			kinds: env.GetList("REACTION_KINDS", "like,love,laugh"),
+		},
+		jobs: jobsConfig{
+			enabled:             env.GetBool("JOBS_ENABLED", true),
+			publishInterval:     time.Second * 30,
+			purgeInterval:       time.Hour,
+			trendingInterval:    time.Minute * 5,
+			linkPreviewInterval: time.Second * 30,
+			viewsFlushInterval:  time.Minute,
+		},
+		trash: trashConfig{
+			retention: time.Hour * 24 * 30, // 30 days
+		},
+		linkPreview: linkPreviewConfig{
+			fetcher: linkpreview.Config{
+				Timeout:      time.Second * 5,
+				MaxBodyBytes: 512 << 10, // 512KB
+				MaxRedirects: 3,
+				UserAgent:    "gopher_social link preview",
+			},
+			maxAge: time.Hour * 24 * 7, // 7 days
+		},
+		comments: commentsConfig{
+			maxDepth: env.GetInt("COMMENTS_MAX_DEPTH", 5),
+		},
 	}
 
 	//database
@@ -133,6 +160,7 @@ func main() {
 		mailer:        mailer,
 		authenticator: jwtAuthenticator,
 		rateLimiter:   rateLimiter,
+		linkPreviews:  linkpreview.NewHTTPFetcher(cfg.linkPreview.fetcher),
 	}
 
 	expvar.NewString("version").Set(version)
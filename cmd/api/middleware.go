@@ -14,46 +14,59 @@ import (
 
 func (app *application) AuthTokenMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		//read the auth header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			app.unauthorizedErrorResponse(w, r, fmt.Errorf("authorization header is missing"))
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			app.unauthorizedErrorResponse(w, r, fmt.Errorf("authorization header is malformed"))
-			return
-		}
-
-		token := parts[1]
-		jwtToken, err := app.authenticator.ValidateToken(token)
+		user, err := app.authenticate(r)
 		if err != nil {
 			app.unauthorizedErrorResponse(w, r, err)
 			return
 		}
 
-		claims, _ := jwtToken.Claims.(jwt.MapClaims)
+		ctx := context.WithValue(r.Context(), userKey, user)
 
-		userID, err := strconv.ParseInt(fmt.Sprintf("%.f", claims["sub"]), 10, 64)
-		if err != nil {
-			app.unauthorizedErrorResponse(w, r, err)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// OptionalAuthTokenMiddleware authenticates requests that carry a token, as
+// AuthTokenMiddleware does, and lets those without one through anonymously.
+// Handlers behind it get the user with getViewerFromContext.
+func (app *application) OptionalAuthTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		ctx := r.Context()
+		app.AuthTokenMiddleware(next).ServeHTTP(w, r)
+	})
+}
 
-		user, err := app.getUserById(ctx, userID)
-		if err != nil {
-			app.unauthorizedErrorResponse(w, r, err)
-			return
-		}
+// authenticate returns the user whose token is in the Authorization header.
+func (app *application) authenticate(r *http.Request) (*store.User, error) {
+	//read the auth header
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, fmt.Errorf("authorization header is missing")
+	}
 
-		ctx = context.WithValue(ctx, userKey, user)
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, fmt.Errorf("authorization header is malformed")
+	}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	token := parts[1]
+	jwtToken, err := app.authenticator.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, _ := jwtToken.Claims.(jwt.MapClaims)
+
+	userID, err := strconv.ParseInt(fmt.Sprintf("%.f", claims["sub"]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.getUserById(r.Context(), userID)
 }
 
 func (app *application) BasicAuthMiddleware() func(http.Handler) http.Handler {
@@ -125,6 +138,54 @@ func (app *application) сheckPostOwnership(role string, next http.HandlerFunc)
 	})
 }
 
+// requireRole lets through users whose role is at least role.
+func (app *application) requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, err := app.checkRolePrecedence(r.Context(), role, app.getUserFromContext(r))
+		if err != nil {
+			app.internalServerErrorResponse(w, r, err)
+			return
+		}
+
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// canViewPost reports whether user may see post. Every handler that exposes
+// a post must go through it, which postContextMiddleware does for routes
+// under /posts/{postId}; list queries apply the same rules in the store.
+// Drafts and scheduled posts are visible to their author only; moderators
+// can see every published post so that they are able to moderate it.
+func (app *application) canViewPost(ctx context.Context, user *store.User, post *store.Post) (bool, error) {
+	if post.UserID == user.ID {
+		return true, nil
+	}
+
+	if post.Status != store.StatusPublished {
+		return false, nil
+	}
+
+	if post.Visibility == store.VisibilityPublic {
+		return true, nil
+	}
+
+	isModerator, err := app.checkRolePrecedence(ctx, "moderator", user)
+	if err != nil {
+		return false, err
+	}
+
+	if isModerator {
+		return true, nil
+	}
+
+	return app.store.Posts.IsVisibleTo(ctx, post.ID, user.ID)
+}
+
 func (app *application) checkRolePrecedence(ctx context.Context, role string, user *store.User) (bool, error) {
 	userRole, err := app.store.Roles.GetByName(ctx, role)
 	if err != nil {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostVisibility(t *testing.T) {
+	author := &store.User{ID: 1, Role: store.Role{Name: "user", Level: 1}}
+	viewer := &store.User{ID: 2, Role: store.Role{Name: "user", Level: 1}}
+	moderator := &store.User{ID: 3, Role: store.Role{Name: "moderator", Level: 2}}
+
+	yes, no := true, false
+
+	tests := []struct {
+		name      string
+		post      store.Post
+		user      *store.User
+		isVisible *bool // what IsVisibleTo answers, nil when it must not be asked
+		expected  int
+	}{
+		{"should show public posts to anyone", store.Post{Visibility: store.VisibilityPublic}, viewer, nil, http.StatusOK},
+		{"should show followers-only posts to their author", store.Post{Visibility: store.VisibilityFollowers}, author, nil, http.StatusOK},
+		{"should show followers-only posts to followers", store.Post{Visibility: store.VisibilityFollowers}, viewer, &yes, http.StatusOK},
+		{"should hide followers-only posts from others", store.Post{Visibility: store.VisibilityFollowers}, viewer, &no, http.StatusNotFound},
+		{"should show mentioned-only posts to mentioned users", store.Post{Visibility: store.VisibilityMentioned}, viewer, &yes, http.StatusOK},
+		{"should hide mentioned-only posts from others", store.Post{Visibility: store.VisibilityMentioned}, viewer, &no, http.StatusNotFound},
+		{"should show followers-only posts to moderators", store.Post{Visibility: store.VisibilityFollowers}, moderator, nil, http.StatusOK},
+		{"should show mentioned-only posts to moderators", store.Post{Visibility: store.VisibilityMentioned}, moderator, nil, http.StatusOK},
+		{"should show drafts to their author", store.Post{Visibility: store.VisibilityPublic, Status: store.StatusDraft}, author, nil, http.StatusOK},
+		{"should hide drafts from others", store.Post{Visibility: store.VisibilityPublic, Status: store.StatusDraft}, viewer, nil, http.StatusNotFound},
+		{"should hide scheduled posts from moderators", store.Post{Visibility: store.VisibilityPublic, Status: store.StatusScheduled}, moderator, nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, config{})
+
+			post := tt.post
+			post.ID = 10
+			post.UserID = author.ID
+			if post.Status == "" {
+				post.Status = store.StatusPublished
+			}
+
+			posts := app.store.Posts.(*store.MockPostsStore)
+			posts.On("GetById", post.ID).Return(&post, nil)
+			if tt.isVisible != nil {
+				posts.On("IsVisibleTo", post.ID, tt.user.ID).Return(*tt.isVisible, nil)
+			}
+
+			app.store.Comments.(*store.MockCommentsStore).On("GetByPostId", post.ID, tt.user.ID, mock.Anything).Return(&store.CommentsPage{}, nil)
+			app.store.Reactions.(*store.MockReactionsStore).On("GetByUser", post.ID, tt.user.ID).Return("", nil)
+			app.store.Polls.(*store.MockPollsStore).On("Get", post.ID, tt.user.ID).Return(nil, nil)
+
+			r := chi.NewRouter()
+			r.With(app.postContextMiddleware).Get("/v1/posts/{postId}", app.getPostHandler)
+
+			rr := executeRequest(r, newRequestAs(t, tt.user, http.MethodGet, "/v1/posts/10", nil))
+
+			checkresponseCode(t, tt.expected, rr.Code)
+
+			if tt.isVisible == nil {
+				posts.AssertNotCalled(t, "IsVisibleTo", mock.Anything, mock.Anything)
+			}
+		})
+	}
+
+	t.Run("should fail when visibility cannot be checked", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		post := store.Post{ID: 10, UserID: author.ID, Visibility: store.VisibilityFollowers, Status: store.StatusPublished}
+
+		posts := app.store.Posts.(*store.MockPostsStore)
+		posts.On("GetById", post.ID).Return(&post, nil)
+		posts.On("IsVisibleTo", post.ID, viewer.ID).Return(false, errors.New("connection refused"))
+
+		r := chi.NewRouter()
+		r.With(app.postContextMiddleware).Get("/v1/posts/{postId}", app.getPostHandler)
+
+		rr := executeRequest(r, newRequestAs(t, viewer, http.MethodGet, "/v1/posts/10", nil))
+
+		checkresponseCode(t, http.StatusInternalServerError, rr.Code)
+	})
+}
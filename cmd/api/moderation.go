@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+var errContentWarningForced = errors.New("the content warning was set by a moderator and cannot be changed")
+
+type SetContentWarningPayload struct {
+	// An empty ContentWarning with Sensitive false lifts the warning and
+	// lets the author change it again.
+	ContentWarning string `json:"content_warning" validate:"max=200"`
+	Sensitive      bool   `json:"sensitive"`
+	Reason         string `json:"reason" validate:"required,max=500"`
+}
+
+// SetContentWarning godoc
+//
+//	@Summary		Applies a content warning to a post
+//	@Description	Sets the content warning and sensitive flag of a post of another user, which its author can then no longer change. The action is recorded in the moderation log.
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Post ID"
+//	@Param			payload	body		SetContentWarningPayload	true	"Content warning payload"
+//	@Success		200		{object}	store.Post
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/content-warning [put]
+func (app *application) setContentWarningHandler(w http.ResponseWriter, r *http.Request) {
+	var payload SetContentWarningPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	moderator := app.getUserFromContext(r)
+	post := app.getPostFromCtx(r)
+
+	cw := store.ContentWarning{
+		ContentWarning: payload.ContentWarning,
+		Sensitive:      payload.Sensitive,
+	}
+
+	version, err := app.store.Moderation.SetContentWarning(r.Context(), post.ID, moderator.ID, cw, payload.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Infow("content warning set by moderator",
+		"post_id", post.ID,
+		"author_id", post.UserID,
+		"moderator_id", moderator.ID,
+		"content_warning", cw.ContentWarning,
+		"sensitive", cw.Sensitive,
+		"reason", payload.Reason,
+	)
+
+	post.ContentWarning = cw.ContentWarning
+	post.Sensitive = cw.Sensitive
+	post.ContentWarningForced = cw.ContentWarning != "" || cw.Sensitive
+	post.Version = version
+
+	w.Header().Set("ETag", postETag(post))
+
+	if err := app.writeResponse(w, http.StatusOK, post); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
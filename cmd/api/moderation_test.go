@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+func TestSetContentWarningHandler(t *testing.T) {
+	moderator := &store.User{ID: 1, Role: store.Role{Name: "moderator"}}
+
+	t.Run("should force the warning on the post", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		cw := store.ContentWarning{ContentWarning: "spoilers", Sensitive: true}
+
+		moderation := app.store.Moderation.(*store.MockModerationStore)
+		moderation.On("SetContentWarning", int64(10), moderator.ID, cw, "unmarked spoilers").Return(3, nil)
+
+		body := `{"content_warning": "spoilers", "sensitive": true, "reason": "unmarked spoilers"}`
+		req := withPost(
+			newRequestAs(t, moderator, http.MethodPut, "/v1/posts/10/content-warning", strings.NewReader(body)),
+			&store.Post{ID: 10, UserID: 2, Version: 2},
+		)
+		rr := executeRequest(http.HandlerFunc(app.setContentWarningHandler), req)
+
+		checkresponseCode(t, http.StatusOK, rr.Code)
+		moderation.AssertExpectations(t)
+
+		var resp struct {
+			Data store.Post `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := resp.Data; !got.ContentWarningForced || got.ContentWarning != "spoilers" || got.Version != 3 {
+			t.Errorf("expected the forced warning at version 3; got %+v", got)
+		}
+	})
+
+	t.Run("should require a reason", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		req := withPost(
+			newRequestAs(t, moderator, http.MethodPut, "/v1/posts/10/content-warning", strings.NewReader(`{"sensitive": true}`)),
+			&store.Post{ID: 10, UserID: 2},
+		)
+		rr := executeRequest(http.HandlerFunc(app.setContentWarningHandler), req)
+
+		checkresponseCode(t, http.StatusBadRequest, rr.Code)
+		app.store.Moderation.(*store.MockModerationStore).AssertNumberOfCalls(t, "SetContentWarning", 0)
+	})
+}
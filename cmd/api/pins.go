@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+type ReorderPinsPayload struct {
+	PostIDs []int64 `json:"post_ids" validate:"max=3"`
+}
+
+// PinPost godoc
+//
+//	@Summary		Pins a post
+//	@Description	Pins a published public post of the current user to their profile, after the posts already pinned. Up to 3 posts can be pinned.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int		true	"Post ID"
+//	@Success		204	{string}	string	"Post pinned"
+//	@Failure		400	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/pin [put]
+func (app *application) pinPostHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+	post := app.getPostFromCtx(r)
+
+	if post.UserID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Pins.Pin(r.Context(), user.ID, post.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		case errors.Is(err, store.ErrNotPinnable):
+			app.badRequestErrorResponse(w, r, err)
+		case errors.Is(err, store.ErrPinLimit):
+			app.conflictErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnpinPost godoc
+//
+//	@Summary		Unpins a post
+//	@Description	Unpins a post of the current user from their profile
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int		true	"Post ID"
+//	@Success		204	{string}	string	"Post unpinned"
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/pin [delete]
+func (app *application) unpinPostHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+	post := app.getPostFromCtx(r)
+
+	if post.UserID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Pins.Unpin(r.Context(), user.ID, post.ID); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderPins godoc
+//
+//	@Summary		Reorders pinned posts
+//	@Description	Sets the order of the pinned posts of the current user, which must all be listed exactly once
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ReorderPinsPayload	true	"Pinned post IDs in their new order"
+//	@Success		204		{string}	string				"Pins reordered"
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/pins [put]
+func (app *application) reorderPinsHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ReorderPinsPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	if err := app.store.Pins.Reorder(r.Context(), user.ID, payload.PostIDs); err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidPinOrder):
+			app.badRequestErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+func TestPinPostHandler(t *testing.T) {
+	user := &store.User{ID: 1}
+
+	tests := []struct {
+		name     string
+		post     *store.Post
+		err      error
+		expected int
+	}{
+		{"should pin own posts", &store.Post{ID: 10, UserID: 1}, nil, http.StatusNoContent},
+		{"should not pin others' posts", &store.Post{ID: 10, UserID: 2}, nil, http.StatusForbidden},
+		{"should not pin hidden posts", &store.Post{ID: 10, UserID: 1}, store.ErrNotPinnable, http.StatusBadRequest},
+		{"should not pin over the limit", &store.Post{ID: 10, UserID: 1}, store.ErrPinLimit, http.StatusConflict},
+		{"should not find deleted posts", &store.Post{ID: 10, UserID: 1}, store.ErrNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, config{})
+
+			pins := app.store.Pins.(*store.MockPinsStore)
+			pins.On("Pin", user.ID, tt.post.ID).Return(tt.err)
+
+			req := withPost(newRequestAs(t, user, http.MethodPut, "/v1/posts/10/pin", nil), tt.post)
+			rr := executeRequest(http.HandlerFunc(app.pinPostHandler), req)
+
+			checkresponseCode(t, tt.expected, rr.Code)
+
+			if tt.expected == http.StatusForbidden {
+				pins.AssertNotCalled(t, "Pin", user.ID, tt.post.ID)
+			}
+		})
+	}
+}
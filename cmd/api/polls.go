@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+type VotePollPayload struct {
+	OptionIDs []int64 `json:"option_ids" validate:"min=1,max=6"`
+}
+
+// VotePoll godoc
+//
+//	@Summary		Votes in a poll
+//	@Description	Votes for one option, or several in multiple choice polls, replacing any previous vote of the current user
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Post ID"
+//	@Param			payload	body		VotePollPayload	true	"Chosen options"
+//	@Success		200		{object}	store.Poll
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/poll/votes [put]
+func (app *application) votePollHandler(w http.ResponseWriter, r *http.Request) {
+	var payload VotePollPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	post := app.getPostFromCtx(r)
+	user := app.getUserFromContext(r)
+	ctx := r.Context()
+
+	slices.Sort(payload.OptionIDs)
+	optionIDs := slices.Compact(payload.OptionIDs)
+
+	if err := app.store.Polls.Vote(ctx, post.ID, user.ID, optionIDs); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		case errors.Is(err, store.ErrInvalidVote):
+			app.badRequestErrorResponse(w, r, err)
+		case errors.Is(err, store.ErrPollClosed):
+			app.conflictErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	poll, err := app.store.Polls.Get(ctx, post.ID, user.ID)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, poll); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// newPoll turns the poll of a create post payload into a store.Poll. The
+// payload has been validated already, except for its closing time.
+func newPoll(payload *CreatePollPayload) (*store.Poll, error) {
+	if payload == nil {
+		return nil, nil
+	}
+
+	poll := &store.Poll{
+		MultipleChoice: payload.MultipleChoice,
+		HideResults:    payload.HideResults,
+	}
+
+	if payload.ClosesAt != nil {
+		if !payload.ClosesAt.After(time.Now()) {
+			return nil, errors.New("closes_at must be in the future")
+		}
+
+		closesAt := payload.ClosesAt.Format(time.RFC3339)
+		poll.ClosesAt = &closesAt
+	}
+
+	for _, text := range payload.Options {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, errors.New("poll options cannot be blank")
+		}
+
+		poll.Options = append(poll.Options, store.PollOption{Text: text})
+	}
+
+	return poll, nil
+}
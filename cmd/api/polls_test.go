@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+func TestVotePollHandler(t *testing.T) {
+	user := &store.User{ID: 1}
+	post := &store.Post{ID: 10, UserID: 2}
+
+	tests := []struct {
+		name     string
+		body     string
+		votes    []int64
+		err      error
+		expected int
+	}{
+		{"should vote", `{"option_ids": [3]}`, []int64{3}, nil, http.StatusOK},
+		{"should drop repeated options", `{"option_ids": [4, 3, 4]}`, []int64{3, 4}, nil, http.StatusOK},
+		{"should require an option", `{"option_ids": []}`, nil, nil, http.StatusBadRequest},
+		{"should reject invalid votes", `{"option_ids": [3, 4]}`, []int64{3, 4}, store.ErrInvalidVote, http.StatusBadRequest},
+		{"should not vote in closed polls", `{"option_ids": [3]}`, []int64{3}, store.ErrPollClosed, http.StatusConflict},
+		{"should not find posts without polls", `{"option_ids": [3]}`, []int64{3}, store.ErrNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, config{})
+
+			polls := app.store.Polls.(*store.MockPollsStore)
+			polls.On("Vote", post.ID, user.ID, tt.votes).Return(tt.err)
+			polls.On("Get", post.ID, user.ID).Return(&store.Poll{}, nil)
+
+			req := withPost(newRequestAs(t, user, http.MethodPut, "/v1/posts/10/poll/votes", strings.NewReader(tt.body)), post)
+			rr := executeRequest(http.HandlerFunc(app.votePollHandler), req)
+
+			checkresponseCode(t, tt.expected, rr.Code)
+
+			if tt.votes == nil {
+				polls.AssertNumberOfCalls(t, "Vote", 0)
+			} else {
+				polls.AssertCalled(t, "Vote", post.ID, user.ID, tt.votes)
+			}
+		})
+	}
+}
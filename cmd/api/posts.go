@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/DenysBahachuk/gopher_social/internal/store"
 	"github.com/go-chi/chi/v5"
@@ -15,9 +20,34 @@ type postContext string
 const postKey postContext = "post"
 
 type СreatePostPayload struct {
-	Title   string   `json:"title" validate:"required,max=100"`
-	Content string   `json:"content" validate:"required,max=1000"`
-	Tags    []string `json:"tags"`
+	Title string `json:"title" validate:"required,max=100"`
+	// Content is Markdown. Posts carry it along with its HTML rendering.
+	Content          string   `json:"content" validate:"required,max=1000"`
+	Tags             []string `json:"tags" validate:"max=10"`
+	QuotedPostID     *int64   `json:"quoted_post_id"`
+	ReplyToPostID    *int64   `json:"reply_to_post_id"`
+	Visibility       string   `json:"visibility" validate:"omitempty,oneof=public followers mentioned"`
+	MentionedUserIDs []int64  `json:"mentioned_user_ids" validate:"max=50"`
+	// Status defaults to published. Scheduled posts need a future PublishAt.
+	Status    string             `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt *time.Time         `json:"publish_at"`
+	Poll      *CreatePollPayload `json:"poll"`
+	// ContentWarning is shown in place of the content until readers choose
+	// to see it.
+	ContentWarning string `json:"content_warning" validate:"max=200"`
+	Sensitive      bool   `json:"sensitive"`
+	// CommentPolicy defaults to everyone.
+	CommentPolicy  string `json:"comment_policy" validate:"omitempty,oneof=everyone followers mentioned off"`
+	CommentsLocked bool   `json:"comments_locked"`
+}
+
+type CreatePollPayload struct {
+	Options        []string `json:"options" validate:"min=2,max=6,dive,required,max=100"`
+	MultipleChoice bool     `json:"multiple_choice"`
+	// HideResults hides the counts from users who haven't voted until the
+	// poll closes.
+	HideResults bool       `json:"hide_results"`
+	ClosesAt    *time.Time `json:"closes_at"`
 }
 
 // CreatePost godoc
@@ -48,22 +78,86 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	status := postPayload.Status
+	if status == "" {
+		status = store.StatusPublished
+	}
+
+	publishAt, err := parsePublishAt(status, postPayload.PublishAt)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	entities := store.ParseEntities(postPayload.Content)
+
+	tags, err := postTags(postPayload.Tags, entities)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	poll, err := newPoll(postPayload.Poll)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
 	user := app.getUserFromContext(r)
 
 	post := store.Post{
-		Content: postPayload.Content,
-		Title:   postPayload.Title,
-		Tags:    postPayload.Tags,
-		UserID:  user.ID,
+		Content:          postPayload.Content,
+		Title:            postPayload.Title,
+		Tags:             tags,
+		Entities:         entities,
+		UserID:           user.ID,
+		QuotedPostID:     postPayload.QuotedPostID,
+		Visibility:       postPayload.Visibility,
+		MentionedUserIDs: postPayload.MentionedUserIDs,
+		Status:           status,
+		PublishAt:        publishAt,
+		Poll:             poll,
+		LinkURL:          linkURL(postPayload.Content),
+		ContentWarning:   postPayload.ContentWarning,
+		Sensitive:        postPayload.Sensitive,
+		CommentPolicy:    postPayload.CommentPolicy,
+		CommentsLocked:   postPayload.CommentsLocked,
 	}
 
 	ctx := r.Context()
 
-	if err := app.store.Posts.Create(ctx, &post); err != nil {
+	if err := app.loadQuotedPost(ctx, user, &post); err != nil {
 		app.internalServerErrorResponse(w, r, err)
 		return
 	}
 
+	if post.QuotedPostID != nil && post.QuotedPost == nil {
+		app.badRequestErrorResponse(w, r, errors.New("quoted post not found"))
+		return
+	}
+
+	if postPayload.ReplyToPostID != nil {
+		if err := app.setReplyTo(ctx, user, &post, *postPayload.ReplyToPostID); err != nil {
+			switch {
+			case errors.Is(err, errReplyToNotFound):
+				app.badRequestErrorResponse(w, r, err)
+			default:
+				app.internalServerErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+
+	if err := app.store.Posts.Create(ctx, &post); err != nil {
+		switch {
+		case errors.Is(err, store.ErrUnknownMention):
+			app.badRequestErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	if err = app.writeResponse(w, http.StatusOK, &post); err != nil {
 		app.internalServerErrorResponse(w, r, err)
 		return
@@ -73,62 +167,102 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 // GetPost godoc
 //
 //	@Summary		Fetches a post
-//	@Description	Fetches a post by ID
+//	@Description	Fetches a post by ID, with the first page of its comments. The ETag changes with anything in the response, and can be sent as If-Match to update or delete the post while its version is unchanged.
 //	@Tags			posts
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int	true	"Post ID"
-//	@Success		200	{object}	store.Post
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
+//	@Param			id				path		int		true	"Post ID"
+//	@Param			If-None-Match	header		string	false	"ETag of a cached copy"
+//	@Success		200				{object}	store.Post
+//	@Success		304				{string}	string	"Not modified"
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{id} [get]
 func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 	post := app.getPostFromCtx(r)
+	user := app.getUserFromContext(r)
+	ctx := r.Context()
+
+	app.recordViews(ctx, user, post)
 
-	comments, err := app.store.Comments.GetByPostId(r.Context(), post.ID)
+	comments, err := app.store.Comments.GetByPostId(ctx, post.ID, user.ID, app.defaultCommentsQuery())
 	if err != nil {
 		app.internalServerErrorResponse(w, r, err)
 		return
 	}
 
-	post.Comments = comments
+	post.Comments = comments.Comments
+	post.CommentsNextCursor = comments.NextCursor
+	post.CommentsCount = comments.TotalCount
 
-	if err = app.writeResponse(w, http.StatusOK, &post); err != nil {
+	post.MyReaction, err = app.store.Reactions.GetByUser(ctx, post.ID, user.ID)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.loadQuotedPost(ctx, user, post); err != nil {
 		app.internalServerErrorResponse(w, r, err)
 		return
 	}
+
+	post.Poll, err = app.store.Polls.Get(ctx, post.ID, user.ID)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	// Comments, reactions and poll results change without the version, and
+	// differ between viewers, so the ETag covers the whole body.
+	body, err := encodeResponse(&post)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	etag := postRepresentationETag(post, body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Vary", "Authorization")
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(body); err != nil {
+		app.logger.Errorw("failed to write response", "path", r.URL.Path, "error", err.Error())
+	}
 }
 
 // DeletePost godoc
 //
 //	@Summary		Deletes a post
-//	@Description	Delete a post by ID
+//	@Description	Moves a post to the trash of its author, from where it can be restored until it is purged
 //	@Tags			posts
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int	true	"Post ID"
-//	@Success		204	{object} string
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
+//	@Param			id			path		int		true	"Post ID"
+//	@Param			If-Match	header		string	true	"ETag of the post"
+//	@Success		204			{object}	string
+//	@Failure		404			{object}	error
+//	@Failure		412			{object}	error
+//	@Failure		428			{object}	error
+//	@Failure		500			{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{id} [delete]
 func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "postId")
-
-	postId, err := strconv.ParseInt(id, 10, 64)
-	if err != nil {
-		app.internalServerErrorResponse(w, r, err)
-		return
-	}
-
-	ctx := r.Context()
+	post := app.getPostFromCtx(r)
+	user := app.getUserFromContext(r)
 
-	err = app.store.Posts.DeleteById(ctx, postId)
+	err := app.store.Posts.DeleteById(r.Context(), post.ID, post.Version, user.ID)
 	if err != nil {
 		switch {
-		case errors.Is(err, store.ErrNotFound):
-			app.notFoundErrorResponse(w, r, err)
+		case errors.Is(err, store.ErrEditConflict):
+			app.preconditionFailedResponse(w, r, err)
 		default:
 			app.internalServerErrorResponse(w, r, err)
 		}
@@ -139,8 +273,19 @@ func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request
 }
 
 type UpdatePostPayload struct {
-	Title   *string `json:"title" validate:"omitempty,max=100"`
-	Content *string `json:"content" validate:"omitempty,max=1000"`
+	Title      *string    `json:"title" validate:"omitempty,max=100"`
+	Content    *string    `json:"content" validate:"omitempty,max=1000"`
+	Visibility *string    `json:"visibility" validate:"omitempty,oneof=public followers mentioned"`
+	Status     *string    `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt  *time.Time `json:"publish_at"`
+	// Tags replaces all tags of the post; an empty list removes them.
+	Tags *[]string `json:"tags" validate:"omitempty,max=10"`
+	// ContentWarning and Sensitive can't be changed once a moderator has
+	// set them.
+	ContentWarning *string `json:"content_warning" validate:"omitempty,max=200"`
+	Sensitive      *bool   `json:"sensitive"`
+	CommentPolicy  *string `json:"comment_policy" validate:"omitempty,oneof=everyone followers mentioned off"`
+	CommentsLocked *bool   `json:"comments_locked"`
 }
 
 // UpdatePost godoc
@@ -150,13 +295,16 @@ type UpdatePostPayload struct {
 //	@Tags			posts
 //	@Accept			json
 //	@Produce		json
-//	@Param			id		path		int					true	"Post ID"
-//	@Param			payload	body		UpdatePostPayload	true	"Post payload"
-//	@Success		200		{object}	store.Post
-//	@Failure		400		{object}	error
-//	@Failure		401		{object}	error
-//	@Failure		404		{object}	error
-//	@Failure		500		{object}	error
+//	@Param			id			path		int					true	"Post ID"
+//	@Param			If-Match	header		string				true	"ETag of the post"
+//	@Param			payload		body		UpdatePostPayload	true	"Post payload"
+//	@Success		200			{object}	store.Post
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		412			{object}	error
+//	@Failure		428			{object}	error
+//	@Failure		500			{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{id} [patch]
 func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request) {
@@ -180,21 +328,220 @@ func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	if postPayload.Content != nil {
-		post.Title = *postPayload.Content
+		post.Content = *postPayload.Content
+		post.Entities = store.ParseEntities(post.Content)
+		post.LinkURL = linkURL(post.Content)
+	}
+
+	if postPayload.Visibility != nil {
+		post.Visibility = *postPayload.Visibility
+	}
+
+	if postPayload.ContentWarning != nil || postPayload.Sensitive != nil {
+		if post.ContentWarningForced {
+			app.badRequestErrorResponse(w, r, errContentWarningForced)
+			return
+		}
+
+		if postPayload.ContentWarning != nil {
+			post.ContentWarning = *postPayload.ContentWarning
+		}
+
+		if postPayload.Sensitive != nil {
+			post.Sensitive = *postPayload.Sensitive
+		}
+	}
+
+	if postPayload.CommentPolicy != nil {
+		post.CommentPolicy = *postPayload.CommentPolicy
+	}
+
+	if postPayload.CommentsLocked != nil {
+		post.CommentsLocked = *postPayload.CommentsLocked
+	}
+
+	if postPayload.Tags != nil || postPayload.Content != nil {
+		tags := post.Tags
+		if postPayload.Tags != nil {
+			tags = *postPayload.Tags
+		}
+
+		post.Tags, err = postTags(tags, post.Entities)
+		if err != nil {
+			app.badRequestErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	if postPayload.Status != nil || postPayload.PublishAt != nil {
+		status := post.Status
+		if postPayload.Status != nil {
+			status = *postPayload.Status
+		}
+
+		if post.Status == store.StatusPublished && status != store.StatusPublished {
+			app.badRequestErrorResponse(w, r, errors.New("a published post cannot be unpublished"))
+			return
+		}
+
+		post.Status = status
+
+		post.PublishAt, err = parsePublishAt(status, postPayload.PublishAt)
+		if err != nil {
+			app.badRequestErrorResponse(w, r, err)
+			return
+		}
 	}
 
-	err = app.store.Posts.UpdateById(r.Context(), post)
+	// The editor is recorded on the revision, which attributes edits made by
+	// moderators through сheckPostOwnership to them rather than the author.
+	editor := app.getUserFromContext(r)
+
+	err = app.store.Posts.UpdateById(r.Context(), post, editor.ID)
 	if err != nil {
-		app.internalServerErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, store.ErrEditConflict):
+			app.preconditionFailedResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
 		return
 	}
 
+	w.Header().Set("ETag", postETag(post))
+
 	if err = app.writeResponse(w, http.StatusOK, post); err != nil {
 		app.internalServerErrorResponse(w, r, err)
 		return
 	}
 }
 
+// postTags normalizes tags and merges the hashtags of the content into
+// them.
+func postTags(tags []string, entities store.Entities) ([]string, error) {
+	tags, err := store.NormalizeTags(slices.Concat(tags, entities.Hashtags()))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tags) > store.MaxTagsPerPost {
+		return nil, fmt.Errorf("a post can have at most %d tags, including hashtags", store.MaxTagsPerPost)
+	}
+
+	return tags, nil
+}
+
+// parsePublishAt checks that publishAt is set, and in the future, exactly
+// when status is scheduled, and formats it for the store.
+func parsePublishAt(status string, publishAt *time.Time) (*string, error) {
+	if status != store.StatusScheduled {
+		if publishAt != nil {
+			return nil, errors.New("publish_at can only be set on scheduled posts")
+		}
+		return nil, nil
+	}
+
+	if publishAt == nil {
+		return nil, errors.New("scheduled posts need a publish_at time")
+	}
+
+	if !publishAt.After(time.Now()) {
+		return nil, errors.New("publish_at must be in the future")
+	}
+
+	formatted := publishAt.Format(time.RFC3339)
+
+	return &formatted, nil
+}
+
+// requirePostIfMatch makes a write conditional on the post still being at
+// the version the client last read, as advertised in the ETag of
+// getPostHandler.
+func (app *application) requirePostIfMatch(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifMatch := r.Header.Get("If-Match")
+		if ifMatch == "" {
+			app.preconditionRequiredResponse(w, r, errors.New("the If-Match header is required"))
+			return
+		}
+
+		post := app.getPostFromCtx(r)
+
+		if !postVersionMatches(ifMatch, post.Version) {
+			app.preconditionFailedResponse(w, r, errors.New("the post has been modified"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// postETag identifies a version of a post. It is sent with responses that
+// only carry its versioned fields.
+func postETag(post *store.Post) string {
+	return fmt.Sprintf(`"%d"`, post.Version)
+}
+
+// postRepresentationETag identifies body, the full representation of post
+// as seen by one viewer. It starts with the version, so it can also be sent
+// as If-Match.
+func postRepresentationETag(post *store.Post, body []byte) string {
+	sum := sha256.Sum256(body)
+
+	return fmt.Sprintf(`"%d-%x"`, post.Version, sum[:8])
+}
+
+// postVersionMatches reports whether header, the value of an If-Match
+// header, lists an ETag of version: either postETag or
+// postRepresentationETag. Edits are only guarded against changes to the
+// post itself, not to its comments or reactions. Weak ETags never match.
+func postVersionMatches(header string, version int) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" {
+			return true
+		}
+
+		tag, ok := strings.CutPrefix(candidate, `"`)
+		if !ok {
+			continue
+		}
+
+		tag, ok = strings.CutSuffix(tag, `"`)
+		if !ok {
+			continue
+		}
+
+		tag, _, _ = strings.Cut(tag, "-")
+
+		if tag == strconv.Itoa(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// etagMatches reports whether etag is listed in header, the value of an
+// If-Match or If-None-Match header. If-None-Match uses weak comparison, which
+// ignores the W/ prefix.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (app *application) postContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "postId")
@@ -218,12 +565,54 @@ func (app *application) postContextMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		visible, err := app.canViewPost(ctx, app.getUserFromContext(r), post)
+		if err != nil {
+			app.internalServerErrorResponse(w, r, err)
+			return
+		}
+
+		// Hidden posts are reported as missing so their existence does not leak.
+		if !visible {
+			app.notFoundErrorResponse(w, r, store.ErrNotFound)
+			return
+		}
+
 		ctx = context.WithValue(ctx, postKey, post)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// loadQuotedPost embeds the compact view of the post quoted by post, if any.
+// QuotedPost is left nil when the quoted post no longer exists or user may
+// not see it.
+func (app *application) loadQuotedPost(ctx context.Context, user *store.User, post *store.Post) error {
+	if post.QuotedPostID == nil {
+		return nil
+	}
+
+	quoted, err := app.store.Posts.GetById(ctx, *post.QuotedPostID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	visible, err := app.canViewPost(ctx, user, quoted)
+	if err != nil {
+		return err
+	}
+
+	if visible {
+		post.QuotedPost = store.NewQuotedPost(quoted)
+	}
+
+	return nil
+}
+
 func (app *application) getPostFromCtx(r *http.Request) *store.Post {
 	return r.Context().Value(postKey).(*store.Post)
 }
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+func TestRequirePostIfMatch(t *testing.T) {
+	app := newTestApplication(t, config{})
+
+	handler := app.requirePostIfMatch(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	newRequest := func(t *testing.T, ifMatch string) *http.Request {
+		req, err := http.NewRequest(http.MethodPatch, "/v1/posts/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+
+		ctx := context.WithValue(req.Context(), postKey, &store.Post{ID: 1, Version: 3})
+
+		return req.WithContext(ctx)
+	}
+
+	tests := []struct {
+		name     string
+		ifMatch  string
+		expected int
+	}{
+		{"should require the If-Match header", "", http.StatusPreconditionRequired},
+		{"should reject a stale version", `"2"`, http.StatusPreconditionFailed},
+		{"should reject a weak ETag", `W/"3"`, http.StatusPreconditionFailed},
+		{"should accept the current version", `"3"`, http.StatusNoContent},
+		{"should accept the current version in a list", `"2", "3"`, http.StatusNoContent},
+		{"should accept a representation of the current version", `"3-0123456789abcdef"`, http.StatusNoContent},
+		{"should reject a representation of a stale version", `"2-0123456789abcdef"`, http.StatusPreconditionFailed},
+		{"should accept a wildcard", "*", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := executeRequest(handler, newRequest(t, tt.ifMatch))
+
+			checkresponseCode(t, tt.expected, rr.Code)
+		})
+	}
+}
+
+func TestPostRepresentationETag(t *testing.T) {
+	post := &store.Post{ID: 1, Version: 3}
+
+	etag := postRepresentationETag(post, []byte(`{"data":{"comments_count":1}}`))
+
+	if !postVersionMatches(etag, post.Version) {
+		t.Errorf("expected %s to match version %d", etag, post.Version)
+	}
+
+	if other := postRepresentationETag(post, []byte(`{"data":{"comments_count":2}}`)); other == etag {
+		t.Errorf("expected different bodies to get different ETags; both got %s", etag)
+	}
+}
+
+func TestUpdatePostHandlerForcedContentWarning(t *testing.T) {
+	app := newTestApplication(t, config{})
+
+	author := &store.User{ID: 1}
+	post := &store.Post{ID: 10, UserID: author.ID, Version: 2, ContentWarning: "spoilers", ContentWarningForced: true}
+
+	for _, body := range []string{`{"content_warning": ""}`, `{"sensitive": false}`} {
+		req := withPost(newRequestAs(t, author, http.MethodPatch, "/v1/posts/10", strings.NewReader(body)), post)
+		rr := executeRequest(http.HandlerFunc(app.updatePostHandler), req)
+
+		checkresponseCode(t, http.StatusBadRequest, rr.Code)
+	}
+
+	app.store.Posts.(*store.MockPostsStore).AssertNumberOfCalls(t, "UpdateById", 0)
+}
+
+func TestGetPostHandlerComments(t *testing.T) {
+	app := newTestApplication(t, config{comments: commentsConfig{maxDepth: 3}})
+
+	user := &store.User{ID: 1}
+	post := &store.Post{ID: 10, UserID: 2, Version: 1}
+
+	firstPage := &store.CommentsPage{
+		Comments:   []*store.Comment{{ID: 30, PostId: post.ID}, {ID: 29, PostId: post.ID}},
+		NextCursor: "next",
+		TotalCount: 7,
+	}
+
+	app.store.Comments.(*store.MockCommentsStore).On("GetByPostId", post.ID, user.ID, app.defaultCommentsQuery()).Return(firstPage, nil)
+	app.store.Reactions.(*store.MockReactionsStore).On("GetByUser", post.ID, user.ID).Return("", nil)
+	app.store.Polls.(*store.MockPollsStore).On("Get", post.ID, user.ID).Return(nil, nil)
+
+	req := withPost(newRequestAs(t, user, http.MethodGet, "/v1/posts/10", nil), post)
+	rr := executeRequest(http.HandlerFunc(app.getPostHandler), req)
+
+	checkresponseCode(t, http.StatusOK, rr.Code)
+
+	var resp struct {
+		Data store.Post `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := resp.Data; len(got.Comments) != 2 || got.CommentsNextCursor != "next" || got.CommentsCount != 7 {
+		t.Errorf("expected the first page of 2 comments out of 7; got %d comments out of %d, cursor %q",
+			len(got.Comments), got.CommentsCount, got.CommentsNextCursor)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+type UpdatePreferencesPayload struct {
+	SensitiveContent string `json:"sensitive_content" validate:"required,oneof=show blur hide"`
+}
+
+// GetPreferences godoc
+//
+//	@Summary		Fetches the preferences of the current user
+//	@Description	Fetches the preferences of the current user. sensitive_content is how posts with a content warning or flagged sensitive are to be shown, which feeds return on each post as content_display.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	store.Preferences
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/preferences [get]
+func (app *application) getPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+
+	prefs, err := app.store.Preferences.Get(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, prefs); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// UpdatePreferences godoc
+//
+//	@Summary		Updates the preferences of the current user
+//	@Description	Updates the preferences of the current user
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		UpdatePreferencesPayload	true	"Preferences payload"
+//	@Success		200		{object}	store.Preferences
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/preferences [put]
+func (app *application) updatePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	var payload UpdatePreferencesPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	prefs := &store.Preferences{SensitiveContent: payload.SensitiveContent}
+
+	if err := app.store.Preferences.Update(r.Context(), user.ID, prefs); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, prefs); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+type ReactionPayload struct {
+	Kind string `json:"kind" validate:"required,max=32"`
+}
+
+// ReactToPost godoc
+//
+//	@Summary		Reacts to a post
+//	@Description	Sets the reaction of the current user to a post, replacing any previous one
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Post ID"
+//	@Param			payload	body		ReactionPayload	true	"Reaction payload"
+//	@Success		204		{string}	string			"Reaction set"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/reactions [put]
+func (app *application) reactToPostHandler(w http.ResponseWriter, r *http.Request) {
+	payload := ReactionPayload{}
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if !slices.Contains(app.config.reactions.kinds, payload.Kind) {
+		app.badRequestErrorResponse(w, r, fmt.Errorf("unknown reaction kind %q", payload.Kind))
+		return
+	}
+
+	user := app.getUserFromContext(r)
+	post := app.getPostFromCtx(r)
+
+	err := app.store.Reactions.Set(r.Context(), post.ID, user.ID, payload.Kind)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnreactToPost godoc
+//
+//	@Summary		Removes a reaction from a post
+//	@Description	Removes the reaction of the current user from a post
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int		true	"Post ID"
+//	@Success		204	{string}	string	"Reaction removed"
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/reactions [delete]
+func (app *application) unreactToPostHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+	post := app.getPostFromCtx(r)
+
+	if err := app.store.Reactions.Delete(r.Context(), post.ID, user.ID); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReactToComment godoc
+//
+//	@Summary		Reacts to a comment
+//	@Description	Sets the reaction of the current user to a comment, replacing any previous one
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int				true	"Post ID"
+//	@Param			commentId	path		int				true	"Comment ID"
+//	@Param			payload		body		ReactionPayload	true	"Reaction payload"
+//	@Success		204			{string}	string			"Reaction set"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentId}/reactions [put]
+func (app *application) reactToCommentHandler(w http.ResponseWriter, r *http.Request) {
+	payload := ReactionPayload{}
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if !slices.Contains(app.config.reactions.kinds, payload.Kind) {
+		app.badRequestErrorResponse(w, r, fmt.Errorf("unknown reaction kind %q", payload.Kind))
+		return
+	}
+
+	user := app.getUserFromContext(r)
+	comment := app.getCommentFromCtx(r)
+
+	err := app.store.CommentReactions.Set(r.Context(), comment.ID, user.ID, payload.Kind)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnreactToComment godoc
+//
+//	@Summary		Removes a reaction from a comment
+//	@Description	Removes the reaction of the current user from a comment
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int		true	"Post ID"
+//	@Param			commentId	path		int		true	"Comment ID"
+//	@Success		204			{string}	string	"Reaction removed"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentId}/reactions [delete]
+func (app *application) unreactToCommentHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+	comment := app.getCommentFromCtx(r)
+
+	if err := app.store.CommentReactions.Delete(r.Context(), comment.ID, user.ID); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+// RepostPost godoc
+//
+//	@Summary		Reposts a post
+//	@Description	Reposts another user's public post to the followers of the current user
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int		true	"Post ID"
+//	@Success		204	{string}	string	"Post reposted"
+//	@Failure		400	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/repost [put]
+func (app *application) repostHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+	post := app.getPostFromCtx(r)
+
+	if post.UserID == user.ID {
+		app.badRequestErrorResponse(w, r, errors.New("cannot repost your own post"))
+		return
+	}
+
+	// Reposts reach the reposter's followers, so only public posts qualify.
+	if post.Visibility != store.VisibilityPublic {
+		app.badRequestErrorResponse(w, r, errors.New("only public posts can be reposted"))
+		return
+	}
+
+	if err := app.store.Reposts.Create(r.Context(), user.ID, post.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UndoRepost godoc
+//
+//	@Summary		Undoes a repost
+//	@Description	Removes the repost of a post by the current user
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int		true	"Post ID"
+//	@Success		204	{string}	string	"Repost removed"
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/repost [delete]
+func (app *application) undoRepostHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+	post := app.getPostFromCtx(r)
+
+	if err := app.store.Reposts.Delete(r.Context(), user.ID, post.ID); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
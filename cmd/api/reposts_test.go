@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+func TestRepostHandler(t *testing.T) {
+	user := &store.User{ID: 1}
+
+	tests := []struct {
+		name     string
+		post     *store.Post
+		err      error
+		expected int
+	}{
+		{"should repost public posts", &store.Post{ID: 10, UserID: 2, Visibility: store.VisibilityPublic}, nil, http.StatusNoContent},
+		{"should not repost own posts", &store.Post{ID: 10, UserID: 1, Visibility: store.VisibilityPublic}, nil, http.StatusBadRequest},
+		{"should not repost followers only posts", &store.Post{ID: 10, UserID: 2, Visibility: store.VisibilityFollowers}, nil, http.StatusBadRequest},
+		{"should not repost mentioned only posts", &store.Post{ID: 10, UserID: 2, Visibility: store.VisibilityMentioned}, nil, http.StatusBadRequest},
+		{"should not find deleted posts", &store.Post{ID: 10, UserID: 2, Visibility: store.VisibilityPublic}, store.ErrNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, config{})
+
+			reposts := app.store.Reposts.(*store.MockRepostsStore)
+			reposts.On("Create", user.ID, tt.post.ID).Return(tt.err)
+
+			req := withPost(newRequestAs(t, user, http.MethodPut, "/v1/posts/10/repost", nil), tt.post)
+			rr := executeRequest(http.HandlerFunc(app.repostHandler), req)
+
+			checkresponseCode(t, tt.expected, rr.Code)
+
+			if tt.expected == http.StatusBadRequest {
+				reposts.AssertNotCalled(t, "Create", user.ID, tt.post.ID)
+			}
+		})
+	}
+}
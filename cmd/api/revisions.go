@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DenysBahachuk/gopher_social/internal/diff"
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+type RevisionDiff struct {
+	From    *store.Revision `json:"from"`
+	To      *store.Revision `json:"to"`
+	Title   []diff.Chunk    `json:"title"`
+	Content []diff.Chunk    `json:"content"`
+}
+
+// GetPostRevisions godoc
+//
+//	@Summary		Fetches the revisions of a post
+//	@Description	Fetches every revision of a post with its editor, newest first
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"Post ID"
+//	@Success		200	{object}	[]store.Revision
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/revisions [get]
+func (app *application) getPostRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	post := app.getPostFromCtx(r)
+
+	revisions, err := app.store.Revisions.GetByPostId(r.Context(), post.ID)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, revisions); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetPostRevisionsDiff godoc
+//
+//	@Summary		Diffs two revisions of a post
+//	@Description	Diffs the title and content of two versions of a post word by word
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int	true	"Post ID"
+//	@Param			from	query		int	false	"Old version, the one before to if omitted"
+//	@Param			to		query		int	false	"New version, the current one if omitted"
+//	@Success		200		{object}	RevisionDiff
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/revisions/diff [get]
+func (app *application) getPostRevisionsDiffHandler(w http.ResponseWriter, r *http.Request) {
+	post := app.getPostFromCtx(r)
+
+	to, err := parseVersion(r, "to", post.Version)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	from, err := parseVersion(r, "from", to-1)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	fromRevision, err := app.store.Revisions.GetByVersion(ctx, post.ID, from)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	toRevision, err := app.store.Revisions.GetByVersion(ctx, post.ID, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	revisionDiff := RevisionDiff{
+		From:    fromRevision,
+		To:      toRevision,
+		Title:   diff.Words(fromRevision.Title, toRevision.Title),
+		Content: diff.Words(fromRevision.Content, toRevision.Content),
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, revisionDiff); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+func parseVersion(r *http.Request, param string, fallback int) (int, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return fallback, nil
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid %s version %q", param, value)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type CreateTagAliasPayload struct {
+	Tag string `json:"tag" validate:"required"`
+}
+
+// GetTagAliases godoc
+//
+//	@Summary		Fetches tag aliases
+//	@Description	Fetches the tags that are merged into other tags
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		store.TagAlias
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tags/aliases [get]
+func (app *application) getTagAliasesHandler(w http.ResponseWriter, r *http.Request) {
+	aliases, err := app.store.Tags.GetAliases(r.Context())
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, aliases); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// CreateTagAlias godoc
+//
+//	@Summary		Merges a tag into another
+//	@Description	Makes alias a synonym of the tag in the payload and retags existing posts. Moderators only.
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			alias	path		string					true	"Tag to merge"
+//	@Param			payload	body		CreateTagAliasPayload	true	"Tag to merge into"
+//	@Success		201		{object}	store.TagAlias
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tags/aliases/{alias} [put]
+func (app *application) createTagAliasHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateTagAliasPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	alias, err := store.NormalizeTag(chi.URLParam(r, "alias"))
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	tag, err := store.NormalizeTag(payload.Tag)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if alias == tag {
+		app.badRequestErrorResponse(w, r, errors.New("a tag cannot be an alias of itself"))
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	tagAlias := &store.TagAlias{
+		Alias:     alias,
+		Tag:       tag,
+		CreatedBy: &user.ID,
+	}
+
+	if err := app.store.Tags.CreateAlias(r.Context(), tagAlias); err != nil {
+		switch {
+		case errors.Is(err, store.ErrConflict):
+			app.conflictErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusCreated, tagAlias); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// DeleteTagAlias godoc
+//
+//	@Summary		Deletes a tag alias
+//	@Description	Stops merging a tag into another. Posts that were retagged keep their tags. Moderators only.
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			alias	path		string	true	"Merged tag"
+//	@Success		204		{string}	string	"Alias deleted"
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tags/aliases/{alias} [delete]
+func (app *application) deleteTagAliasHandler(w http.ResponseWriter, r *http.Request) {
+	alias, err := store.NormalizeTag(chi.URLParam(r, "alias"))
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Tags.DeleteAlias(r.Context(), alias); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTagPosts godoc
+//
+//	@Summary		Fetches the timeline of a tag
+//	@Description	Fetches the posts tagged with a tag, or with an alias of it, newest first. Anonymous callers only get public posts
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			tag		path		string	true	"Tag"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Param			since	query		string	false	"Since"
+//	@Param			until	query		string	false	"Until"
+//	@Success		200		{object}	store.PostsPage
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tags/{tag}/posts [get]
+func (app *application) getTagPostsHandler(w http.ResponseWriter, r *http.Request) {
+	tag, err := store.NormalizeTag(chi.URLParam(r, "tag"))
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	ppq := store.PaginatedPostsQuery{
+		Limit: 20,
+	}
+
+	if err := ppq.Parse(r); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(ppq); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	// Anonymous callers see what a user following no one and mentioned
+	// nowhere would: zero is never a user id.
+	var viewerID int64
+	if viewer := app.getViewerFromContext(r); viewer != nil {
+		viewerID = viewer.ID
+	}
+
+	page, err := app.store.Posts.GetByTag(r.Context(), tag, viewerID, ppq)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// FollowTag godoc
+//
+//	@Summary		Follows a tag
+//	@Description	Adds the posts tagged with a tag to the feed of the current user
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			tag	path		string	true	"Tag"
+//	@Success		204	{string}	string	"Tag followed"
+//	@Failure		400	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tags/{tag}/follow [put]
+func (app *application) followTagHandler(w http.ResponseWriter, r *http.Request) {
+	tag, err := store.NormalizeTag(chi.URLParam(r, "tag"))
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	if err := app.store.Tags.Follow(r.Context(), user.ID, tag); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnfollowTag godoc
+//
+//	@Summary		Unfollows a tag
+//	@Description	Stops adding the posts tagged with a tag to the feed of the current user
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			tag	path		string	true	"Tag"
+//	@Success		204	{string}	string	"Tag unfollowed"
+//	@Failure		400	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tags/{tag}/follow [delete]
+func (app *application) unfollowTagHandler(w http.ResponseWriter, r *http.Request) {
+	tag, err := store.NormalizeTag(chi.URLParam(r, "tag"))
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	if err := app.store.Tags.Unfollow(r.Context(), user.ID, tag); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetFollowedTags godoc
+//
+//	@Summary		Fetches followed tags
+//	@Description	Fetches the tags followed by the current user
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		string
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/tags [get]
+func (app *application) getFollowedTagsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+
+	tags, err := app.store.Tags.GetFollowed(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, tags); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetTagPostsHandler(t *testing.T) {
+	page := &store.PostsPage{Posts: []*store.PostForFeed{}}
+
+	t.Run("should let anonymous callers through as viewer zero", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+		mux := app.mount()
+
+		posts := app.store.Posts.(*store.MockPostsStore)
+		posts.On("GetByTag", "go", int64(0), mock.Anything).Return(page, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/tags/go/posts", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := executeRequest(mux, req)
+
+		checkresponseCode(t, http.StatusOK, rr.Code)
+		posts.AssertExpectations(t)
+	})
+
+	t.Run("should reject invalid tokens", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+		mux := app.mount()
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/tags/go/posts", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Token abc")
+
+		rr := executeRequest(mux, req)
+
+		checkresponseCode(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should list the posts as seen by the caller", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+		user := &store.User{ID: 7}
+
+		posts := app.store.Posts.(*store.MockPostsStore)
+		posts.On("GetByTag", "go", user.ID, mock.Anything).Return(page, nil)
+
+		r := chi.NewRouter()
+		r.Get("/v1/tags/{tag}/posts", app.getTagPostsHandler)
+
+		rr := executeRequest(r, newRequestAs(t, user, http.MethodGet, "/v1/tags/Go/posts", nil))
+
+		checkresponseCode(t, http.StatusOK, rr.Code)
+		posts.AssertExpectations(t)
+	})
+
+	t.Run("should keep the other tag routes authenticated", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+		mux := app.mount()
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/tags/aliases", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := executeRequest(mux, req)
+
+		checkresponseCode(t, http.StatusUnauthorized, rr.Code)
+	})
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -52,3 +54,28 @@ func checkresponseCode(t *testing.T, expected, actual int) {
 		t.Errorf("Expected response code %d. Got %d", expected, actual)
 	}
 }
+
+// newRequestAs returns a request made by user, for handlers mounted without
+// the authentication middleware.
+func newRequestAs(t *testing.T, user *store.User, method, target string, body io.Reader) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), userKey, user))
+}
+
+// withPost returns req with post in its context, as postContextMiddleware
+// leaves it.
+func withPost(req *http.Request, post *store.Post) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), postKey, post))
+}
+
+// withComment returns req with comment in its context, as
+// commentContextMiddleware leaves it.
+func withComment(req *http.Request, comment *store.Comment) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), commentKey, comment))
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetThread godoc
+//
+//	@Summary		Fetches a conversation
+//	@Description	Fetches a post and its replies as a tree, oldest replies first. Deleted or hidden posts that have replies are returned as tombstones. Replies cut at the limit continue with the next_cursor of their parent, and levels cut at the depth by fetching the thread of their post.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int		true	"Post ID"
+//	@Param			depth	query		int		false	"Levels of replies, up to 5"
+//	@Param			limit	query		int		false	"Replies per post, up to 20"
+//	@Param			cursor	query		string	false	"Cursor into the replies of the post"
+//	@Success		200		{object}	store.ThreadNode
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/threads/{id} [get]
+func (app *application) getThreadHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.ParseInt(chi.URLParam(r, "postId"), 10, 64)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	tq := store.ThreadQuery{
+		Depth: 3,
+		Limit: 10,
+	}
+
+	if err := tq.Parse(r); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(tq); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	thread, err := app.store.Posts.GetThread(r.Context(), postID, user.ID, tq)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, thread); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+var errReplyToNotFound = errors.New("the post replied to was not found")
+
+// setReplyTo makes post a reply to the post with id replyToID, which user
+// must be able to see. errReplyToNotFound means it can't be replied to.
+func (app *application) setReplyTo(ctx context.Context, user *store.User, post *store.Post, replyToID int64) error {
+	parent, err := app.store.Posts.GetById(ctx, replyToID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return errReplyToNotFound
+		default:
+			return err
+		}
+	}
+
+	if parent.Status != store.StatusPublished {
+		return errReplyToNotFound
+	}
+
+	visible, err := app.canViewPost(ctx, user, parent)
+	if err != nil {
+		return err
+	}
+
+	if !visible {
+		return errReplyToNotFound
+	}
+
+	post.ReplyToPostID = &parent.ID
+
+	post.ThreadID = parent.ThreadID
+	if post.ThreadID == nil {
+		post.ThreadID = &parent.ID
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+const purgeBatchSize = 100
+
+type trashConfig struct {
+	retention time.Duration
+}
+
+// GetTrash godoc
+//
+//	@Summary		Fetches the trash
+//	@Description	Fetches the posts and comments of the current user that were deleted, by them or by a moderator, and can still be restored, most recently deleted first
+//	@Tags			trash
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Limit, up to 50"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Success		200		{object}	store.Trash
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/trash [get]
+func (app *application) getTrashHandler(w http.ResponseWriter, r *http.Request) {
+	tq := store.TrashQuery{
+		Limit: 20,
+	}
+
+	if err := tq.Parse(r); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(tq); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	trash, err := app.store.Trash.Get(r.Context(), user.ID, app.trashCutoff(), tq)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, trash); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// RestorePost godoc
+//
+//	@Summary		Restores a post
+//	@Description	Takes a post out of the trash. Authors can restore their posts, whoever deleted them, and admins can restore any post.
+//	@Tags			trash
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int		true	"Post ID"
+//	@Success		204	{string}	string	"Post restored"
+//	@Failure		400	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/trash/posts/{id}/restore [post]
+func (app *application) restorePostHandler(w http.ResponseWriter, r *http.Request) {
+	app.restoreFromTrash(w, r, "postId", app.store.Trash.RestorePost)
+}
+
+// RestoreComment godoc
+//
+//	@Summary		Restores a comment
+//	@Description	Takes a comment out of the trash. Authors can restore their comments, whoever deleted them, and admins can restore any comment.
+//	@Tags			trash
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int		true	"Comment ID"
+//	@Success		204	{string}	string	"Comment restored"
+//	@Failure		400	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/trash/comments/{id}/restore [post]
+func (app *application) restoreCommentHandler(w http.ResponseWriter, r *http.Request) {
+	app.restoreFromTrash(w, r, "commentId", app.store.Trash.RestoreComment)
+}
+
+type restoreFunc func(ctx context.Context, id, userId int64, since time.Time, anyone bool) error
+
+func (app *application) restoreFromTrash(w http.ResponseWriter, r *http.Request, param string, restore restoreFunc) {
+	id, err := strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+	ctx := r.Context()
+
+	isAdmin, err := app.checkRolePrecedence(ctx, "admin", user)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := restore(ctx, id, user.ID, app.trashCutoff(), isAdmin); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// trashCutoff is the deletion time before which items can no longer be
+// restored and are due for purging.
+func (app *application) trashCutoff() time.Time {
+	return time.Now().Add(-app.config.trash.retention)
+}
+
+// purgeTrash permanently deletes what has been in the trash for longer than
+// the retention window.
+func (app *application) purgeTrash(ctx context.Context) error {
+	for {
+		purged, err := app.store.Trash.Purge(ctx, app.trashCutoff(), purgeBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if purged == 0 {
+			return nil
+		}
+
+		app.logger.Infow("purged trash", "rows", purged)
+	}
+}
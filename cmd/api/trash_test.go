@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetTrashHandler(t *testing.T) {
+	user := &store.User{ID: 1}
+
+	t.Run("should page through the trash", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		cursor := store.Cursor{CreatedAt: "2024-01-01T00:00:00Z", ID: 5, Kind: "comment"}
+
+		trash := app.store.Trash.(*store.MockTrashStore)
+		trash.On("Get", user.ID, mock.Anything, store.TrashQuery{Limit: 5, Cursor: &cursor}).
+			Return(&store.Trash{Posts: []store.TrashedPost{}, Comments: []store.TrashedComment{}}, nil)
+
+		rr := executeRequest(
+			http.HandlerFunc(app.getTrashHandler),
+			newRequestAs(t, user, http.MethodGet, "/v1/users/me/trash?limit=5&cursor="+cursor.Encode(), nil),
+		)
+
+		checkresponseCode(t, http.StatusOK, rr.Code)
+		trash.AssertExpectations(t)
+	})
+
+	t.Run("should reject a cursor from another listing", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		cursor := store.Cursor{CreatedAt: "2024-01-01T00:00:00Z", ID: 5}
+
+		rr := executeRequest(
+			http.HandlerFunc(app.getTrashHandler),
+			newRequestAs(t, user, http.MethodGet, "/v1/users/me/trash?cursor="+cursor.Encode(), nil),
+		)
+
+		checkresponseCode(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+)
+
+// trendingSize is how many entries of each trending list are kept.
+const trendingSize = 100
+
+// GetTrendingTags godoc
+//
+//	@Summary		Fetches trending tags
+//	@Description	Fetches the tags trending over the last day, highest score first
+//	@Tags			trending
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit, up to 50"
+//	@Success		200		{array}		store.TrendingTag
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/trending/tags [get]
+func (app *application) getTrendingTagsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseTrendingLimit(r)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	var tags []store.TrendingTag
+	if app.config.redisCfg.enabled {
+		tags, err = app.cacheStorage.Trending.GetTags(r.Context(), limit)
+	} else {
+		tags, err = app.store.Trending.GetTags(r.Context(), limit)
+	}
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, tags); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetTrendingPosts godoc
+//
+//	@Summary		Fetches trending posts
+//	@Description	Fetches the public posts trending over the last day, highest score first
+//	@Tags			trending
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit, up to 50"
+//	@Success		200		{array}		store.PostForFeed
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/trending/posts [get]
+func (app *application) getTrendingPostsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseTrendingLimit(r)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	var trending []store.TrendingPost
+	if app.config.redisCfg.enabled {
+		trending, err = app.cacheStorage.Trending.GetPosts(ctx, limit)
+	} else {
+		trending, err = app.store.Trending.GetPosts(ctx, limit)
+	}
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	ids := make([]int64, len(trending))
+	for i, t := range trending {
+		ids[i] = t.PostID
+	}
+
+	user := app.getUserFromContext(r)
+
+	// Posts deleted or hidden since the last refresh are left out.
+	posts, err := app.store.Posts.GetByIds(ctx, user.ID, ids)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, posts); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+func parseTrendingLimit(r *http.Request) (int, error) {
+	limit := r.URL.Query().Get("limit")
+	if limit == "" {
+		return 10, nil
+	}
+
+	l, err := strconv.Atoi(limit)
+	if err != nil || l < 1 || l > 50 {
+		return 0, fmt.Errorf("limit must be between 1 and 50")
+	}
+
+	return l, nil
+}
+
+// refreshTrending recomputes the trending lists, into Redis when it is
+// enabled and into the materialized views otherwise.
+func (app *application) refreshTrending(ctx context.Context) error {
+	if !app.config.redisCfg.enabled {
+		return app.store.Trending.Refresh(ctx)
+	}
+
+	posts, err := app.store.Trending.ComputePosts(ctx, trendingSize)
+	if err != nil {
+		return err
+	}
+
+	if err := app.cacheStorage.Trending.SetPosts(ctx, posts); err != nil {
+		return err
+	}
+
+	tags, err := app.store.Trending.ComputeTags(ctx, trendingSize)
+	if err != nil {
+		return err
+	}
+
+	return app.cacheStorage.Trending.SetTags(ctx, tags)
+}
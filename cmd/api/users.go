@@ -51,6 +51,155 @@ func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetUserPosts godoc
+//
+//	@Summary		Fetches the posts of a user
+//	@Description	Fetches the posts authored by a user: the posts they pinned first, marked as pinned, then the others newest first. Only published posts visible to the caller are listed; drafts, scheduled and deleted posts are left out, and so is everything when the user has blocked the caller
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int		true	"User ID"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Param			tags	query		string	false	"Tags"
+//	@Param			since	query		string	false	"Since"
+//	@Param			until	query		string	false	"Until"
+//	@Success		200		{object}	store.PostsPage
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{id}/posts [get]
+func (app *application) getUserPostsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	ppq := store.PaginatedPostsQuery{
+		Limit: 20,
+	}
+
+	if err := ppq.Parse(r); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(ppq); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	if _, err := app.getUserById(ctx, userID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	viewer := app.getUserFromContext(r)
+
+	page, err := app.store.Posts.GetByUser(ctx, userID, viewer.ID, ppq)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetDrafts godoc
+//
+//	@Summary		Fetches the drafts of the current user
+//	@Description	Fetches the draft and scheduled posts of the current user, newest first
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Success		200		{object}	store.PostsPage
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/drafts [get]
+func (app *application) getDraftsHandler(w http.ResponseWriter, r *http.Request) {
+	ppq := store.PaginatedPostsQuery{
+		Limit: 20,
+	}
+
+	if err := ppq.Parse(r); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(ppq); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	page, err := app.store.Posts.GetDrafts(r.Context(), user.ID, ppq)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetMentions godoc
+//
+//	@Summary		Fetches the posts mentioning the current user
+//	@Description	Fetches the posts that mention the current user, newest first
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Success		200		{object}	store.PostsPage
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/mentions [get]
+func (app *application) getMentionsHandler(w http.ResponseWriter, r *http.Request) {
+	ppq := store.PaginatedPostsQuery{
+		Limit: 20,
+	}
+
+	if err := ppq.Parse(r); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(ppq); err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	user := app.getUserFromContext(r)
+
+	page, err := app.store.Posts.GetMentioning(r.Context(), user.ID, ppq)
+	if err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
 type FollowerUserPayload struct {
 	UserId int64 `json:"userId"`
 }
@@ -130,6 +279,81 @@ func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// BlockUser godoc
+//
+//	@Summary		Blocks a user
+//	@Description	Blocks a user by ID: the posts of the current user are hidden from them, and follows between them are dropped
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userId	path		int		true	"User ID"
+//	@Success		204		{string}	string	"User blocked"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error	"User not found"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userId}/block [put]
+func (app *application) blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+
+	blockedID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if blockedID == user.ID {
+		app.badRequestErrorResponse(w, r, errors.New("users cannot block themselves"))
+		return
+	}
+
+	if err := app.store.Blocks.Block(r.Context(), user.ID, blockedID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundErrorResponse(w, r, err)
+		default:
+			app.internalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
+// UnblockUser godoc
+//
+//	@Summary		Unblocks a user
+//	@Description	Unblocks a user by ID
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userId	path		int		true	"User ID"
+//	@Success		204		{string}	string	"User unblocked"
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userId}/unblock [put]
+func (app *application) unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserFromContext(r)
+
+	blockedID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		app.badRequestErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Blocks.Unblock(r.Context(), user.ID, blockedID); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerErrorResponse(w, r, err)
+	}
+}
+
 // ActivateUser gdoc
 //
 //	@Summary		Activates/Registers a user
@@ -192,3 +416,10 @@ func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Reque
 func (app *application) getUserFromContext(r *http.Request) *store.User {
 	return r.Context().Value(userKey).(*store.User)
 }
+
+// getViewerFromContext returns the user of a request that may be anonymous,
+// behind OptionalAuthTokenMiddleware, or nil when it is.
+func (app *application) getViewerFromContext(r *http.Request) *store.User {
+	user, _ := r.Context().Value(userKey).(*store.User)
+	return user
+}
@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/DenysBahachuk/gopher_social/internal/store"
 	"github.com/DenysBahachuk/gopher_social/internal/store/cache"
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -85,3 +87,58 @@ func TestGetUserHandler(t *testing.T) {
 		mockCacheStore.Calls = nil // Reset mock expectations
 	})
 }
+
+func TestBlockUserHandler(t *testing.T) {
+	user := &store.User{ID: 1}
+
+	newRouter := func(app *application) http.Handler {
+		r := chi.NewRouter()
+		r.Put("/v1/users/{userId}/block", app.blockUserHandler)
+		r.Put("/v1/users/{userId}/unblock", app.unblockUserHandler)
+
+		return r
+	}
+
+	t.Run("should block a user", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		blocks := app.store.Blocks.(*store.MockBlocksStore)
+		blocks.On("Block", user.ID, int64(2)).Return(nil)
+
+		rr := executeRequest(newRouter(app), newRequestAs(t, user, http.MethodPut, "/v1/users/2/block", nil))
+
+		checkresponseCode(t, http.StatusNoContent, rr.Code)
+		blocks.AssertExpectations(t)
+	})
+
+	t.Run("should not block oneself", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		rr := executeRequest(newRouter(app), newRequestAs(t, user, http.MethodPut, "/v1/users/1/block", nil))
+
+		checkresponseCode(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should not find unknown users", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		blocks := app.store.Blocks.(*store.MockBlocksStore)
+		blocks.On("Block", user.ID, int64(2)).Return(store.ErrNotFound)
+
+		rr := executeRequest(newRouter(app), newRequestAs(t, user, http.MethodPut, "/v1/users/2/block", nil))
+
+		checkresponseCode(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("should unblock a user", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+
+		blocks := app.store.Blocks.(*store.MockBlocksStore)
+		blocks.On("Unblock", user.ID, int64(2)).Return(nil)
+
+		rr := executeRequest(newRouter(app), newRequestAs(t, user, http.MethodPut, "/v1/users/2/unblock", nil))
+
+		checkresponseCode(t, http.StatusNoContent, rr.Code)
+		blocks.AssertExpectations(t)
+	})
+}
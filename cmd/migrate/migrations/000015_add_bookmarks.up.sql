@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS bookmark_collections (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookmark_collections_default
+ON bookmark_collections (user_id) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+    collection_id bigint NOT NULL,
+    post_id bigint NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (collection_id, post_id),
+    FOREIGN KEY (collection_id) REFERENCES bookmark_collections (id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookmarks_post_id ON bookmarks (post_id);
@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS posts
+DROP COLUMN IF EXISTS quoted_post_id;
+
+DROP TABLE IF EXISTS reposts;
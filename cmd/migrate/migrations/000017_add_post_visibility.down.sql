@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS mentions;
+
+ALTER TABLE IF EXISTS posts
+DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS posts
+ALTER COLUMN version DROP NOT NULL;
+
+DROP TABLE IF EXISTS post_revisions;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id bigserial PRIMARY KEY,
+    post_id bigint NOT NULL,
+    version INT NOT NULL,
+    title text NOT NULL,
+    content text NOT NULL,
+    editor_id bigint NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+    UNIQUE (post_id, version),
+    FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    FOREIGN KEY (editor_id) REFERENCES users (id)
+);
+
+INSERT INTO
+    post_revisions (post_id, version, title, content, editor_id, created_at)
+SELECT
+    id, COALESCE(version, 0), title, content, user_id, updated_at
+FROM
+    posts;
+
+UPDATE posts SET version = 0 WHERE version IS NULL;
+
+ALTER TABLE
+    posts
+ALTER COLUMN
+    version SET NOT NULL;
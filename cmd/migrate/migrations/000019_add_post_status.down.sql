@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS posts
+DROP COLUMN IF EXISTS publish_at;
+
+ALTER TABLE IF EXISTS posts
+DROP COLUMN IF EXISTS status;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS tag_aliases (
+    alias VARCHAR(32) PRIMARY KEY,
+    tag VARCHAR(32) NOT NULL,
+    created_by bigint,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+    CHECK (alias <> tag),
+    FOREIGN KEY (created_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tag_aliases_tag ON tag_aliases (tag);
+
+-- Bring the tags of existing posts to their canonical form, which tag
+-- filters now compare against.
+UPDATE posts
+SET tags = ARRAY(
+    SELECT c.tag FROM (
+        SELECT lower(ltrim(btrim(t.tag), '#')) AS tag, MIN(t.n) AS n
+        FROM unnest(posts.tags) WITH ORDINALITY AS t(tag, n)
+        WHERE ltrim(btrim(t.tag), '#') <> ''
+        GROUP BY 1
+    ) c
+    ORDER BY c.n
+);
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS comment_mentions;
+
+ALTER TABLE IF EXISTS mentions
+DROP COLUMN IF EXISTS explicit;
+
+ALTER TABLE IF EXISTS comments
+DROP COLUMN IF EXISTS entities;
+
+ALTER TABLE IF EXISTS posts
+DROP COLUMN IF EXISTS entities;
@@ -0,0 +1,28 @@
+ALTER TABLE
+    posts
+ADD
+    COLUMN entities jsonb NOT NULL DEFAULT '[]';
+
+ALTER TABLE
+    comments
+ADD
+    COLUMN entities jsonb NOT NULL DEFAULT '[]';
+
+-- Mentions made through mentioned_user_ids are explicit. The others come
+-- from the content and follow it as it is edited.
+ALTER TABLE
+    mentions
+ADD
+    COLUMN explicit boolean NOT NULL DEFAULT true;
+
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    comment_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (comment_id, user_id),
+    FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_mentions_user_id ON comment_mentions (user_id);
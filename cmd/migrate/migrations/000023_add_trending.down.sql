@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_posts_created_at;
+DROP INDEX IF EXISTS idx_post_reactions_created_at;
+DROP INDEX IF EXISTS idx_comments_created_at;
+
+DROP MATERIALIZED VIEW IF EXISTS trending_tags;
+DROP MATERIALIZED VIEW IF EXISTS trending_posts;
+
+DROP VIEW IF EXISTS trending_tag_scores;
+DROP VIEW IF EXISTS trending_post_scores;
@@ -0,0 +1,67 @@
+-- Trending scores are computed over the last 24 hours. Every event counts
+-- for its weight, halved for every 6 hours of age: a post being created
+-- counts 1, a comment 2 and a reaction 1. Only one comment per user and
+-- post counts, and the author's own comments and reactions don't count.
+-- Only public posts can trend.
+CREATE OR REPLACE VIEW trending_post_scores AS
+WITH events AS (
+    SELECT p.id AS post_id, p.created_at AS at, 1.0 AS weight
+    FROM posts p
+    WHERE p.created_at > NOW() - INTERVAL '24 hours'
+
+    UNION ALL
+
+    SELECT c.post_id, MAX(c.created_at), 2.0
+    FROM comments c
+    JOIN posts p ON p.id = c.post_id
+    WHERE c.created_at > NOW() - INTERVAL '24 hours' AND c.deleted_at IS NULL AND c.user_id <> p.user_id
+    GROUP BY c.post_id, c.user_id
+
+    UNION ALL
+
+    SELECT r.post_id, r.created_at, 1.0
+    FROM post_reactions r
+    JOIN posts p ON p.id = r.post_id
+    WHERE r.created_at > NOW() - INTERVAL '24 hours' AND r.user_id <> p.user_id
+),
+scores AS (
+    SELECT post_id, SUM(weight * EXP(-LN(2) * EXTRACT(EPOCH FROM NOW() - at) / 21600)) AS score
+    FROM events
+    GROUP BY post_id
+)
+SELECT
+    p.id AS post_id,
+    p.user_id,
+    p.tags,
+    s.score::double precision AS score,
+    ROW_NUMBER() OVER (PARTITION BY p.user_id ORDER BY s.score DESC, p.id DESC) AS author_rank
+FROM scores s
+JOIN posts p ON p.id = s.post_id
+WHERE p.deleted_at IS NULL AND p.status = 'published' AND p.visibility = 'public';
+
+-- A tag scores the best post of every author using it, so that a single
+-- account can't push a tag by posting it over and over.
+CREATE OR REPLACE VIEW trending_tag_scores AS
+SELECT tag, SUM(best)::double precision AS score
+FROM (
+    SELECT t.tag, s.user_id, MAX(s.score) AS best
+    FROM trending_post_scores s, unnest(s.tags) AS t(tag)
+    GROUP BY t.tag, s.user_id
+) author_scores
+GROUP BY tag;
+
+-- The materialized views serve the trending endpoints when Redis is
+-- disabled. No author has more than 2 posts trending at once.
+CREATE MATERIALIZED VIEW IF NOT EXISTS trending_posts AS
+SELECT post_id, score FROM trending_post_scores WHERE author_rank <= 2;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trending_posts_post_id ON trending_posts (post_id);
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS trending_tags AS
+SELECT tag, score FROM trending_tag_scores;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trending_tags_tag ON trending_tags (tag);
+
+CREATE INDEX IF NOT EXISTS idx_comments_created_at ON comments (created_at);
+CREATE INDEX IF NOT EXISTS idx_post_reactions_created_at ON post_reactions (created_at);
+CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts (created_at);
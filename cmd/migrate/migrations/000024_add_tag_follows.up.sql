@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS tag_follows (
+    user_id bigint NOT NULL,
+    tag VARCHAR(32) NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, tag),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tag_follows_tag ON tag_follows (tag);
@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS posts
+DROP COLUMN IF EXISTS thread_id;
+
+ALTER TABLE IF EXISTS posts
+DROP COLUMN IF EXISTS reply_to_post_id;
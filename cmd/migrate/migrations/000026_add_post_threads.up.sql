@@ -0,0 +1,14 @@
+-- Replies keep pointing at their parent and thread root after these are
+-- deleted, so there are no foreign keys: missing posts show as tombstones.
+ALTER TABLE
+    posts
+ADD
+    COLUMN reply_to_post_id bigint;
+
+ALTER TABLE
+    posts
+ADD
+    COLUMN thread_id bigint;
+
+CREATE INDEX IF NOT EXISTS idx_posts_reply_to_post_id ON posts (reply_to_post_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_posts_thread_id ON posts (thread_id);
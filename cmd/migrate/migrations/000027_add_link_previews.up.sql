@@ -0,0 +1,18 @@
+ALTER TABLE
+    posts
+ADD
+    COLUMN link_url text;
+
+-- Previews are shared by every post linking to the same URL. Failed fetches
+-- are recorded too, so they are not retried until the preview goes stale.
+CREATE TABLE IF NOT EXISTS link_previews (
+    url text PRIMARY KEY,
+    title text NOT NULL DEFAULT '',
+    description text NOT NULL DEFAULT '',
+    image_url text NOT NULL DEFAULT '',
+    site_name text NOT NULL DEFAULT '',
+    failed boolean NOT NULL DEFAULT false,
+    fetched_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_posts_link_url ON posts (link_url) WHERE link_url IS NOT NULL;
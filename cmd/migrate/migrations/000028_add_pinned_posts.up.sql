@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS pinned_posts (
+    user_id bigint NOT NULL,
+    post_id bigint NOT NULL,
+    position int NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, post_id),
+    UNIQUE (post_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS moderation_log;
+
+DROP TABLE IF EXISTS user_preferences;
+
+ALTER TABLE IF EXISTS posts
+DROP COLUMN IF EXISTS content_warning_forced,
+DROP COLUMN IF EXISTS sensitive,
+DROP COLUMN IF EXISTS content_warning;
@@ -0,0 +1,31 @@
+ALTER TABLE
+    posts
+ADD
+    COLUMN content_warning VARCHAR(200) NOT NULL DEFAULT '',
+ADD
+    COLUMN sensitive BOOLEAN NOT NULL DEFAULT FALSE,
+ADD
+    COLUMN content_warning_forced BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id bigint PRIMARY KEY,
+    sensitive_content VARCHAR(8) NOT NULL DEFAULT 'blur'
+    CHECK (sensitive_content IN ('show', 'blur', 'hide')),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- The log outlives the posts and users it refers to, so it has no foreign
+-- keys.
+CREATE TABLE IF NOT EXISTS moderation_log (
+    id bigserial PRIMARY KEY,
+    moderator_id bigint NOT NULL,
+    post_id bigint NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    details jsonb NOT NULL DEFAULT '{}',
+    reason text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_log_post_id ON moderation_log (post_id);
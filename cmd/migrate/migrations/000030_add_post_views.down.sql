@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_followers_follower_id_created_at;
+
+DROP TABLE IF EXISTS post_views_daily;
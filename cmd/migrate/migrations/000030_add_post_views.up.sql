@@ -0,0 +1,11 @@
+-- Views are counted in Redis and flushed here in batches. Days are UTC.
+CREATE TABLE IF NOT EXISTS post_views_daily (
+    post_id bigint NOT NULL,
+    day date NOT NULL,
+    views bigint NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (post_id, day),
+    FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_followers_follower_id_created_at ON followers (follower_id, created_at);
@@ -0,0 +1,6 @@
+ALTER TABLE
+    comments
+ADD
+    COLUMN edited_at timestamp(0) with time zone;
+
+CREATE INDEX IF NOT EXISTS idx_comments_post_id_created_at ON comments (post_id, created_at, id) WHERE deleted_at IS NULL;
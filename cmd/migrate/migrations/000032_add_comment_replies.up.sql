@@ -0,0 +1,8 @@
+ALTER TABLE
+    comments
+ADD
+    COLUMN parent_comment_id bigint REFERENCES comments (id) ON DELETE CASCADE,
+ADD
+    COLUMN depth int NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_comments_parent_comment_id ON comments (parent_comment_id, created_at, id);
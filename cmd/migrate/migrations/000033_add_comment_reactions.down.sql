@@ -0,0 +1,8 @@
+ALTER TABLE IF EXISTS posts
+DROP COLUMN IF EXISTS highlighted_comment_id;
+
+ALTER TABLE IF EXISTS comments
+DROP COLUMN IF EXISTS reactions_count,
+DROP COLUMN IF EXISTS reaction_counts;
+
+DROP TABLE IF EXISTS comment_reactions;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS comment_reactions (
+    comment_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (comment_id, user_id),
+    FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_reactions_user_id ON comment_reactions (user_id);
+
+-- reactions_count is the sum of reaction_counts, which "top" ordering ranks
+-- comments by.
+ALTER TABLE
+    comments
+ADD
+    COLUMN reaction_counts JSONB NOT NULL DEFAULT '{}',
+ADD
+    COLUMN reactions_count int NOT NULL DEFAULT 0;
+
+ALTER TABLE
+    posts
+ADD
+    COLUMN highlighted_comment_id bigint REFERENCES comments (id) ON DELETE SET NULL;
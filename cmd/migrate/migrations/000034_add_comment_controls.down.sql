@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS comments
+DROP COLUMN IF EXISTS hidden_at;
+
+ALTER TABLE IF EXISTS posts
+DROP COLUMN IF EXISTS comments_locked,
+DROP COLUMN IF EXISTS comment_policy;
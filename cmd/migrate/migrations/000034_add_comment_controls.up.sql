@@ -0,0 +1,11 @@
+ALTER TABLE
+    posts
+ADD
+    COLUMN comment_policy varchar(16) NOT NULL DEFAULT 'everyone',
+ADD
+    COLUMN comments_locked boolean NOT NULL DEFAULT false;
+
+ALTER TABLE
+    comments
+ADD
+    COLUMN hidden_at timestamp(0) with time zone;
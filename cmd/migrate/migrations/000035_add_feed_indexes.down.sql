@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_reposts_user_id_created_at;
+
+DROP INDEX IF EXISTS idx_posts_created_at_id;
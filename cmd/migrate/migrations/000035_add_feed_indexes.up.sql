@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_posts_created_at_id ON posts (created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_reposts_user_id_created_at ON reposts (user_id, created_at);
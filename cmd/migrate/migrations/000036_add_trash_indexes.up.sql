@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_posts_trash ON posts (user_id, deleted_at, id) WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_comments_trash ON comments (user_id, deleted_at, id) WHERE deleted_at IS NOT NULL;
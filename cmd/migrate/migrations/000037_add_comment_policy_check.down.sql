@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS posts
+DROP CONSTRAINT IF EXISTS posts_comment_policy_check;
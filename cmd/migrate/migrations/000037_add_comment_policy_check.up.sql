@@ -0,0 +1,5 @@
+ALTER TABLE
+    posts
+ADD
+    CONSTRAINT posts_comment_policy_check
+    CHECK (comment_policy IN ('everyone', 'followers', 'mentioned', 'off'));
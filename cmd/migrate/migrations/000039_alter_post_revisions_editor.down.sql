@@ -0,0 +1,16 @@
+UPDATE post_revisions r
+SET editor_id = p.user_id
+FROM posts p
+WHERE p.id = r.post_id AND r.editor_id IS NULL;
+
+ALTER TABLE
+    post_revisions
+DROP CONSTRAINT IF EXISTS post_revisions_editor_id_fkey,
+ADD
+    CONSTRAINT post_revisions_editor_id_fkey
+    FOREIGN KEY (editor_id) REFERENCES users (id);
+
+ALTER TABLE
+    post_revisions
+ALTER COLUMN
+    editor_id SET NOT NULL;
@@ -0,0 +1,11 @@
+ALTER TABLE
+    post_revisions
+ALTER COLUMN
+    editor_id DROP NOT NULL;
+
+ALTER TABLE
+    post_revisions
+DROP CONSTRAINT IF EXISTS post_revisions_editor_id_fkey,
+ADD
+    CONSTRAINT post_revisions_editor_id_fkey
+    FOREIGN KEY (editor_id) REFERENCES users (id) ON DELETE SET NULL;
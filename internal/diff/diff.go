@@ -0,0 +1,103 @@
+// Package diff computes word level differences between two texts.
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// Chunk is a run of text that is kept, inserted or deleted when going from
+// the old text to the new one.
+type Chunk struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Words diffs a and b word by word, keeping the whitespace that follows each
+// word attached to it, so concatenating the Equal and Insert chunks yields b
+// and concatenating the Equal and Delete chunks yields a.
+func Words(a, b string) []Chunk {
+	x, y := tokenize(a), tokenize(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var chunks []Chunk
+
+	add := func(op, text string) {
+		if n := len(chunks); n > 0 && chunks[n-1].Op == op {
+			chunks[n-1].Text += text
+			return
+		}
+		chunks = append(chunks, Chunk{Op: op, Text: text})
+	}
+
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			add(Equal, x[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(Delete, x[i])
+			i++
+		default:
+			add(Insert, y[j])
+			j++
+		}
+	}
+
+	for ; i < len(x); i++ {
+		add(Delete, x[i])
+	}
+
+	for ; j < len(y); j++ {
+		add(Insert, y[j])
+	}
+
+	return chunks
+}
+
+func tokenize(s string) []string {
+	var tokens []string
+
+	for len(s) > 0 {
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end == -1 {
+			tokens = append(tokens, s)
+			break
+		}
+
+		next := strings.IndexFunc(s[end:], func(r rune) bool { return !unicode.IsSpace(r) })
+		if next == -1 {
+			tokens = append(tokens, s)
+			break
+		}
+
+		tokens = append(tokens, s[:end+next])
+		s = s[end+next:]
+	}
+
+	return tokens
+}
@@ -0,0 +1,50 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	t.Run("should mark changed words", func(t *testing.T) {
+		got := Words("the quick brown fox", "the slow brown dog")
+
+		expected := []Chunk{
+			{Op: Equal, Text: "the "},
+			{Op: Delete, Text: "quick "},
+			{Op: Insert, Text: "slow "},
+			{Op: Equal, Text: "brown "},
+			{Op: Delete, Text: "fox"},
+			{Op: Insert, Text: "dog"},
+		}
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %+v; got %+v", expected, got)
+		}
+	})
+
+	t.Run("should rebuild both texts from the chunks", func(t *testing.T) {
+		a := "  Leading space and\ttabs\nnew lines "
+		b := "Leading space, tabs\nand new lines"
+
+		var oldText, newText string
+		for _, c := range Words(a, b) {
+			if c.Op != Insert {
+				oldText += c.Text
+			}
+			if c.Op != Delete {
+				newText += c.Text
+			}
+		}
+
+		if oldText != a || newText != b {
+			t.Errorf("expected %q and %q; got %q and %q", a, b, oldText, newText)
+		}
+	})
+
+	t.Run("should return no chunks for two empty texts", func(t *testing.T) {
+		if got := Words("", ""); len(got) != 0 {
+			t.Errorf("expected no chunks; got %+v", got)
+		}
+	})
+}
@@ -3,6 +3,7 @@ package env
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 func GetString(key string, fallback string) string {
@@ -41,3 +42,17 @@ func GetBool(key string, fallback bool) bool {
 
 	return valAsBool
 }
+
+// GetList splits a comma separated value, trimming the entries and dropping
+// blank ones.
+func GetList(key string, fallback string) []string {
+	var list []string
+
+	for _, entry := range strings.Split(GetString(key, fallback), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,35 @@
+package env
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetList(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"plain", "like,love", []string{"like", "love"}},
+		{"spaces", " like , love ", []string{"like", "love"}},
+		{"blanks", "like,, ,love,", []string{"like", "love"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_LIST", tt.value)
+
+			if got := GetList("TEST_LIST", "fallback"); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %q; got %q", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("fallback", func(t *testing.T) {
+		if got := GetList("TEST_LIST_UNSET", "like, love"); !slices.Equal(got, []string{"like", "love"}) {
+			t.Errorf("expected the fallback; got %q", got)
+		}
+	})
+}
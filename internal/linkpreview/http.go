@@ -0,0 +1,230 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// HTTPFetcher fetches pages over the network. It only connects to public
+// addresses: the check is made on the resolved address of every connection,
+// redirects included, so DNS names pointing inside the network are refused
+// too.
+type HTTPFetcher struct {
+	client       *http.Client
+	maxBodyBytes int64
+	userAgent    string
+}
+
+func NewHTTPFetcher(cfg Config) *HTTPFetcher {
+	return newHTTPFetcher(cfg, isPublicAddr)
+}
+
+func newHTTPFetcher(cfg Config, allowAddr func(netip.Addr) bool) *HTTPFetcher {
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+
+			if !allowAddr(addrPort.Addr().Unmap()) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+			}
+
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		// Going through a proxy would defeat the address check.
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.Timeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       time.Minute,
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return errors.New("too many redirects")
+			}
+			if !isHTTP(req.URL) {
+				return fmt.Errorf("%w: redirect to %s", ErrBlockedAddress, req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+
+	return &HTTPFetcher{
+		client:       client,
+		maxBodyBytes: cfg.MaxBodyBytes,
+		userAgent:    cfg.UserAgent,
+	}
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (*Preview, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isHTTP(pageURL) {
+		return nil, fmt.Errorf("%w: %s", ErrBlockedAddress, pageURL.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "text/html")
+	if f.userAgent != "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNotHTML
+	}
+
+	// The final URL, after redirects, is what relative links resolve
+	// against.
+	preview := parse(io.LimitReader(res.Body, f.maxBodyBytes), res.Request.URL)
+	preview.URL = rawURL
+
+	return preview, nil
+}
+
+// parse reads the metadata in the head of an HTML document. OpenGraph
+// properties win over Twitter card ones, which win over plain HTML.
+func parse(r io.Reader, pageURL *url.URL) *Preview {
+	meta := map[string]string{}
+	var title string
+
+	z := html.NewTokenizer(r)
+
+	for {
+		tt := z.Next()
+
+		switch tt {
+		case html.ErrorToken:
+			return newPreview(meta, title, pageURL)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+
+			switch string(name) {
+			case "body":
+				return newPreview(meta, title, pageURL)
+			case "title":
+				if title == "" && z.Next() == html.TextToken {
+					title = strings.TrimSpace(string(z.Text()))
+				}
+			case "meta":
+				var key, content string
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = z.TagAttr()
+					switch string(k) {
+					case "property", "name":
+						key = strings.ToLower(string(v))
+					case "content":
+						content = strings.TrimSpace(string(v))
+					}
+				}
+				if _, ok := meta[key]; !ok && key != "" && content != "" {
+					meta[key] = content
+				}
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				return newPreview(meta, title, pageURL)
+			}
+		}
+	}
+}
+
+func newPreview(meta map[string]string, title string, pageURL *url.URL) *Preview {
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if v := meta[key]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	preview := &Preview{
+		Title:       first("og:title", "twitter:title"),
+		Description: first("og:description", "twitter:description", "description"),
+		SiteName:    first("og:site_name"),
+	}
+
+	if preview.Title == "" {
+		preview.Title = title
+	}
+
+	if image := first("og:image", "og:image:url", "twitter:image"); image != "" {
+		if imageURL, err := pageURL.Parse(image); err == nil && isHTTP(imageURL) {
+			preview.Image = imageURL.String()
+		}
+	}
+
+	return preview
+}
+
+func isHTTP(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64
+}
+
+// isPublicAddr reports whether addr is routable on the public internet.
+func isPublicAddr(addr netip.Addr) bool {
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,183 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testConfig = Config{
+	Timeout:      time.Second,
+	MaxBodyBytes: 4096,
+	MaxRedirects: 3,
+}
+
+func allowAll(netip.Addr) bool { return true }
+
+func newOrigin(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	origin := httptest.NewServer(handler)
+	t.Cleanup(origin.Close)
+
+	return origin
+}
+
+func TestHTTPFetcher(t *testing.T) {
+	t.Run("should read OpenGraph metadata", func(t *testing.T) {
+		origin := newOrigin(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<html><head>
+				<title>Plain title</title>
+				<meta name="twitter:title" content="Card title">
+				<meta property="og:title" content="OG title">
+				<meta name="description" content="A description">
+				<meta property="og:image" content="/cover.png">
+				<meta property="og:site_name" content="Example">
+			</head><body><meta property="og:description" content="ignored"></body></html>`))
+		})
+
+		preview, err := newHTTPFetcher(testConfig, allowAll).Fetch(context.Background(), origin.URL+"/page")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := Preview{
+			URL:         origin.URL + "/page",
+			Title:       "OG title",
+			Description: "A description",
+			Image:       origin.URL + "/cover.png",
+			SiteName:    "Example",
+		}
+
+		if *preview != expected {
+			t.Errorf("expected %+v; got %+v", expected, *preview)
+		}
+	})
+
+	t.Run("should fall back to the title tag", func(t *testing.T) {
+		origin := newOrigin(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title> Plain title </title></head></html>`))
+		})
+
+		preview, err := newHTTPFetcher(testConfig, allowAll).Fetch(context.Background(), origin.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if preview.Title != "Plain title" {
+			t.Errorf("expected the title tag; got %q", preview.Title)
+		}
+	})
+
+	t.Run("should refuse private addresses", func(t *testing.T) {
+		var hit bool
+		origin := newOrigin(t, func(w http.ResponseWriter, r *http.Request) {
+			hit = true
+		})
+
+		_, err := NewHTTPFetcher(testConfig).Fetch(context.Background(), origin.URL)
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("expected ErrBlockedAddress; got %v", err)
+		}
+
+		if hit {
+			t.Error("expected the origin not to be reached")
+		}
+	})
+
+	t.Run("should refuse redirects to other schemes", func(t *testing.T) {
+		origin := newOrigin(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+		})
+
+		_, err := newHTTPFetcher(testConfig, allowAll).Fetch(context.Background(), origin.URL)
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("expected ErrBlockedAddress; got %v", err)
+		}
+	})
+
+	t.Run("should stop reading at the size cap", func(t *testing.T) {
+		origin := newOrigin(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><meta property="og:title" content="` + strings.Repeat("a", 5000) + `">`))
+			w.Write([]byte(`<meta property="og:description" content="past the cap"></head></html>`))
+		})
+
+		preview, err := newHTTPFetcher(testConfig, allowAll).Fetch(context.Background(), origin.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if preview.Description != "" {
+			t.Errorf("expected nothing past the cap to be read; got %q", preview.Description)
+		}
+	})
+
+	t.Run("should time out on slow origins", func(t *testing.T) {
+		origin := newOrigin(t, func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+			}
+		})
+
+		cfg := testConfig
+		cfg.Timeout = 100 * time.Millisecond
+
+		if _, err := newHTTPFetcher(cfg, allowAll).Fetch(context.Background(), origin.URL); err == nil {
+			t.Error("expected a timeout")
+		}
+	})
+
+	t.Run("should refuse other content types", func(t *testing.T) {
+		origin := newOrigin(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+		})
+
+		_, err := newHTTPFetcher(testConfig, allowAll).Fetch(context.Background(), origin.URL)
+		if !errors.Is(err, ErrNotHTML) {
+			t.Errorf("expected ErrNotHTML; got %v", err)
+		}
+	})
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+	} {
+		if got := isPublicAddr(netip.MustParseAddr(addr)); got != public {
+			t.Errorf("isPublicAddr(%s) = %v; expected %v", addr, got, public)
+		}
+	}
+}
+
+func TestFirstURL(t *testing.T) {
+	for content, expected := range map[string]string{
+		"see https://example.com/a?b=c.":         "https://example.com/a?b=c",
+		"(https://en.wikipedia.org/wiki/Go_(x))": "https://en.wikipedia.org/wiki/Go_(x)",
+		"(see http://example.com)":               "http://example.com",
+		"no links here":                          "",
+	} {
+		if got := FirstURL(content); got != expected {
+			t.Errorf("FirstURL(%q) = %q; expected %q", content, got, expected)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBlockedAddress = errors.New("address not allowed")
+	ErrNotHTML        = errors.New("not an HTML page")
+)
+
+// Preview is the OpenGraph or Twitter card metadata of a page, falling back
+// to its <title> and description.
+type Preview struct {
+	URL         string
+	Title       string
+	Description string
+	Image       string
+	SiteName    string
+}
+
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (*Preview, error)
+}
+
+type Config struct {
+	Timeout      time.Duration
+	MaxBodyBytes int64
+	MaxRedirects int
+	UserAgent    string
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// FirstURL returns the first http(s) URL in content, or an empty string.
+// Punctuation that ends a sentence is not taken as part of the URL.
+func FirstURL(content string) string {
+	url := urlPattern.FindString(content)
+
+	url = strings.TrimRight(url, ".,;:!?")
+	if strings.Count(url, "(") < strings.Count(url, ")") {
+		url = strings.TrimSuffix(url, ")")
+	}
+
+	return url
+}
@@ -0,0 +1,58 @@
+package markdown
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache keeps the renderings of the most recently rendered sources. Keys
+// must change whenever the source does, e.g. by including its version.
+type Cache[K comparable] struct {
+	mu      sync.Mutex
+	size    int
+	entries map[K]*list.Element
+	// order holds the keys from the most to the least recently used.
+	order *list.List
+}
+
+type cacheEntry[K comparable] struct {
+	key  K
+	html string
+}
+
+func NewCache[K comparable](size int) *Cache[K] {
+	return &Cache[K]{
+		size:    size,
+		entries: make(map[K]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// Render returns the rendering of src cached under key, rendering it on a
+// miss.
+func (c *Cache[K]) Render(key K, src string) string {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*cacheEntry[K]).html
+	}
+	c.mu.Unlock()
+
+	html := Render(src)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.order.PushFront(&cacheEntry[K]{key: key, html: html})
+
+		if c.order.Len() > c.size {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*cacheEntry[K]).key)
+		}
+	}
+
+	return html
+}
@@ -0,0 +1,472 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+type inlineKind int
+
+const (
+	textInline inlineKind = iota
+	htmlInline
+	delimInline
+)
+
+// inline is a piece of rendered inline content. Delimiter runs of * and _
+// are kept apart until emphasis is resolved; what is left of them is text.
+type inline struct {
+	kind     inlineKind
+	value    string
+	char     rune
+	count    int
+	original int
+	canOpen  bool
+	canClose bool
+}
+
+func (in *inline) html() string {
+	switch in.kind {
+	case textInline:
+		return html.EscapeString(in.value)
+	case delimInline:
+		return strings.Repeat(string(in.char), in.count)
+	default:
+		return in.value
+	}
+}
+
+type inlineParser struct {
+	src []rune
+	pos int
+	// inLink disables links and autolinks, which can't nest.
+	inLink bool
+	items  []*inline
+	text   strings.Builder
+}
+
+func renderInline(src string) string {
+	p := &inlineParser{src: []rune(src)}
+	return p.parse()
+}
+
+func (p *inlineParser) parse() string {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+
+		switch {
+		case c == '\\' && p.pos+1 < len(p.src) && isASCIIPunct(p.src[p.pos+1]):
+			p.text.WriteRune(p.src[p.pos+1])
+			p.pos += 2
+		case c == '\\' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '\n':
+			p.add(&inline{kind: htmlInline, value: "<br>\n"})
+			p.pos += 2
+		case c == '\n':
+			p.lineBreak()
+		case c == '`':
+			p.codeSpan()
+		case c == '*' || c == '_':
+			p.delimiterRun()
+		case c == '[' && !p.inLink:
+			if !p.link() {
+				p.text.WriteRune(c)
+				p.pos++
+			}
+		case c == '<' && !p.inLink:
+			if !p.autolink() {
+				p.text.WriteRune(c)
+				p.pos++
+			}
+		case c == 'h' && !p.inLink && p.startsBareURL():
+			p.bareURL()
+		default:
+			p.text.WriteRune(c)
+			p.pos++
+		}
+	}
+
+	p.flushText()
+	resolveEmphasis(&p.items)
+
+	return renderItems(p.items)
+}
+
+func (p *inlineParser) flushText() {
+	if p.text.Len() > 0 {
+		p.items = append(p.items, &inline{kind: textInline, value: p.text.String()})
+		p.text.Reset()
+	}
+}
+
+func (p *inlineParser) add(in *inline) {
+	p.flushText()
+	p.items = append(p.items, in)
+}
+
+// lineBreak turns a newline preceded by two spaces or more into a hard
+// break. Other newlines are kept as they are.
+func (p *inlineParser) lineBreak() {
+	text := p.text.String()
+	trimmed := strings.TrimRight(text, " ")
+
+	p.text.Reset()
+	p.text.WriteString(trimmed)
+
+	if len(text)-len(trimmed) >= 2 {
+		p.add(&inline{kind: htmlInline, value: "<br>\n"})
+	} else {
+		p.text.WriteRune('\n')
+	}
+
+	p.pos++
+}
+
+// codeSpan renders a code span, or the backticks as text when the run is not
+// closed by one of the same length.
+func (p *inlineParser) codeSpan() {
+	n := p.runLength(p.pos, '`')
+	start := p.pos + n
+
+	for i := start; i < len(p.src); {
+		if p.src[i] != '`' {
+			i++
+			continue
+		}
+
+		m := p.runLength(i, '`')
+		if m == n {
+			code := strings.ReplaceAll(string(p.src[start:i]), "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+
+			p.add(&inline{kind: htmlInline, value: "<code>" + html.EscapeString(code) + "</code>"})
+			p.pos = i + m
+			return
+		}
+		i += m
+	}
+
+	p.text.WriteString(strings.Repeat("`", n))
+	p.pos = start
+}
+
+func (p *inlineParser) runLength(i int, c rune) int {
+	n := 0
+	for i+n < len(p.src) && p.src[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// delimiterRun records a run of * or _ with whether it can open or close
+// emphasis, following the flanking rules of CommonMark.
+func (p *inlineParser) delimiterRun() {
+	c := p.src[p.pos]
+	n := p.runLength(p.pos, c)
+
+	before, after := ' ', ' '
+	if p.pos > 0 {
+		before = p.src[p.pos-1]
+	}
+	if p.pos+n < len(p.src) {
+		after = p.src[p.pos+n]
+	}
+
+	leftFlanking := !unicode.IsSpace(after) &&
+		(!isPunct(after) || unicode.IsSpace(before) || isPunct(before))
+	rightFlanking := !unicode.IsSpace(before) &&
+		(!isPunct(before) || unicode.IsSpace(after) || isPunct(after))
+
+	in := &inline{kind: delimInline, char: c, count: n, original: n}
+	if c == '*' {
+		in.canOpen = leftFlanking
+		in.canClose = rightFlanking
+	} else {
+		in.canOpen = leftFlanking && (!rightFlanking || isPunct(before))
+		in.canClose = rightFlanking && (!leftFlanking || isPunct(after))
+	}
+
+	p.add(in)
+	p.pos += n
+}
+
+// link renders an inline link [text](destination "title"). It reports false
+// when the brackets don't start one. The title is accepted but not rendered.
+func (p *inlineParser) link() bool {
+	end := p.closingBracket(p.pos)
+	if end < 0 || end+1 >= len(p.src) || p.src[end+1] != '(' {
+		return false
+	}
+
+	i := end + 2
+	i = p.skipSpace(i)
+
+	var dest string
+	if i < len(p.src) && p.src[i] == '<' {
+		j := i + 1
+		for j < len(p.src) && p.src[j] != '>' && p.src[j] != '\n' && p.src[j] != '<' {
+			j++
+		}
+		if j >= len(p.src) || p.src[j] != '>' {
+			return false
+		}
+		dest = string(p.src[i+1 : j])
+		i = j + 1
+	} else {
+		j, depth := i, 0
+		for j < len(p.src) && !unicode.IsSpace(p.src[j]) && !unicode.IsControl(p.src[j]) {
+			if p.src[j] == '(' {
+				depth++
+			} else if p.src[j] == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+			j++
+		}
+		dest = string(p.src[i:j])
+		i = j
+	}
+
+	i = p.skipSpace(i)
+	if i < len(p.src) && (p.src[i] == '"' || p.src[i] == '\'') {
+		quote := p.src[i]
+		j := i + 1
+		for j < len(p.src) && p.src[j] != quote {
+			j++
+		}
+		if j >= len(p.src) {
+			return false
+		}
+		i = p.skipSpace(j + 1)
+	}
+
+	if i >= len(p.src) || p.src[i] != ')' {
+		return false
+	}
+
+	text := &inlineParser{src: p.src[p.pos+1 : end], inLink: true}
+	p.add(&inline{kind: htmlInline, value: anchor(unescape(dest), text.parse())})
+	p.pos = i + 1
+
+	return true
+}
+
+// closingBracket returns the index of the ] matching the [ at i, or -1.
+func (p *inlineParser) closingBracket(i int) int {
+	depth := 0
+
+	for ; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case '`':
+			// Brackets in code spans don't count.
+			n := p.runLength(i, '`')
+			j := i + n
+			for j < len(p.src) {
+				if p.src[j] != '`' {
+					j++
+					continue
+				}
+				m := p.runLength(j, '`')
+				if m == n {
+					break
+				}
+				j += m
+			}
+			if j < len(p.src) {
+				i = j + n - 1
+			} else {
+				i += n - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func (p *inlineParser) skipSpace(i int) int {
+	for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t' || p.src[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// autolink renders <scheme:...>, reporting false when the angle bracket
+// doesn't start one.
+func (p *inlineParser) autolink() bool {
+	j := p.pos + 1
+	for j < len(p.src) && p.src[j] != '>' && p.src[j] != '<' && !unicode.IsSpace(p.src[j]) {
+		j++
+	}
+
+	if j >= len(p.src) || p.src[j] != '>' {
+		return false
+	}
+
+	dest := string(p.src[p.pos+1 : j])
+	if !strings.Contains(dest, ":") {
+		return false
+	}
+
+	p.add(&inline{kind: htmlInline, value: anchor(dest, html.EscapeString(dest))})
+	p.pos = j + 1
+
+	return true
+}
+
+func (p *inlineParser) startsBareURL() bool {
+	if p.pos > 0 && (unicode.IsLetter(p.src[p.pos-1]) || unicode.IsDigit(p.src[p.pos-1])) {
+		return false
+	}
+
+	rest := string(p.src[p.pos:min(len(p.src), p.pos+8)])
+	return strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://")
+}
+
+// bareURL links a URL written as text. Punctuation ending a sentence is not
+// taken as part of it, nor an unbalanced closing parenthesis.
+func (p *inlineParser) bareURL() {
+	j := p.pos
+	for j < len(p.src) && !unicode.IsSpace(p.src[j]) && p.src[j] != '<' {
+		j++
+	}
+
+	dest := strings.TrimRight(string(p.src[p.pos:j]), ".,;:!?*_~'\"")
+	if strings.Count(dest, "(") < strings.Count(dest, ")") {
+		dest = strings.TrimSuffix(dest, ")")
+	}
+
+	p.add(&inline{kind: htmlInline, value: anchor(dest, html.EscapeString(dest))})
+	p.pos += len([]rune(dest))
+}
+
+// anchor renders a link to dest, or only its text when dest is not a URL
+// with an allowed scheme.
+func anchor(dest, text string) string {
+	href, ok := safeURL(dest)
+	if !ok {
+		return text
+	}
+
+	return `<a href="` + html.EscapeString(href) + `" rel="nofollow ugc">` + text + `</a>`
+}
+
+var allowedSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// safeURL reports whether raw is an absolute URL with an allowed scheme, and
+// returns it normalized.
+func safeURL(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || !allowedSchemes[strings.ToLower(u.Scheme)] {
+		return "", false
+	}
+
+	if u.Scheme != "mailto" && u.Host == "" {
+		return "", false
+	}
+
+	return u.String(), true
+}
+
+// resolveEmphasis matches delimiter runs into em and strong elements, going
+// through closers from left to right and pairing each with the nearest
+// compatible opener before it.
+func resolveEmphasis(items *[]*inline) {
+	list := *items
+
+	for j := 0; j < len(list); j++ {
+		closer := list[j]
+		if closer.kind != delimInline || !closer.canClose || closer.count == 0 {
+			continue
+		}
+
+		i := j - 1
+		for ; i >= 0; i-- {
+			opener := list[i]
+			if opener.kind != delimInline || opener.char != closer.char || !opener.canOpen || opener.count == 0 {
+				continue
+			}
+
+			// The "multiple of 3" rule keeps **a*b** from pairing the
+			// single * with a side of the double one.
+			if (opener.canClose || closer.canOpen) &&
+				(opener.original+closer.original)%3 == 0 &&
+				(opener.original%3 != 0 || closer.original%3 != 0) {
+				continue
+			}
+
+			break
+		}
+
+		if i < 0 {
+			continue
+		}
+
+		opener := list[i]
+
+		n, tag := 1, "em"
+		if opener.count >= 2 && closer.count >= 2 {
+			n, tag = 2, "strong"
+		}
+
+		opener.count -= n
+		closer.count -= n
+
+		wrapped := &inline{
+			kind:  htmlInline,
+			value: "<" + tag + ">" + renderItems(list[i+1:j]) + "</" + tag + ">",
+		}
+
+		list = append(list[:i+1], append([]*inline{wrapped}, list[j:]...)...)
+
+		// Look at the closer again, it may have delimiters left.
+		j = i + 1
+	}
+
+	*items = list
+}
+
+func renderItems(items []*inline) string {
+	var b strings.Builder
+
+	for _, in := range items {
+		b.WriteString(in.html())
+	}
+
+	return b.String()
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) && isASCIIPunct(runes[i+1]) {
+			i++
+		}
+		b.WriteRune(runes[i])
+	}
+
+	return b.String()
+}
+
+func isASCIIPunct(r rune) bool {
+	return r < unicode.MaxASCII && unicode.IsPunct(r) || strings.ContainsRune("$+<=>^`|~", r)
+}
+
+func isPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
@@ -0,0 +1,188 @@
+// Package markdown renders the subset of CommonMark supported in posts to
+// HTML that is safe to embed in a page.
+//
+// The subset is made of paragraphs, bullet and ordered lists, fenced code
+// blocks, emphasis, code spans, links and autolinks. Bare http(s) URLs are
+// linked too. Raw HTML is not supported and is shown as text.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Render converts src to HTML. The output only ever contains the elements
+// and attributes allowed by Sanitize, and links are marked nofollow ugc.
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+
+	p := &blockParser{lines: strings.Split(src, "\n")}
+
+	return Sanitize(p.render())
+}
+
+var (
+	fencePattern    = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)")
+	listItemPattern = regexp.MustCompile(`^ {0,3}([-*+]|\d{1,9}[.)])( +|$)`)
+	languagePattern = regexp.MustCompile(`^[a-zA-Z0-9_+#-]+$`)
+)
+
+type blockParser struct {
+	lines []string
+	pos   int
+	out   strings.Builder
+}
+
+func (p *blockParser) render() string {
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+
+		switch {
+		case isBlank(line):
+			p.pos++
+		case fencePattern.MatchString(line):
+			p.codeBlock()
+		case listItemPattern.MatchString(line):
+			p.list()
+		default:
+			p.paragraph()
+		}
+	}
+
+	return strings.TrimSuffix(p.out.String(), "\n")
+}
+
+// codeBlock renders a fenced code block. An unclosed fence runs to the end
+// of the content.
+func (p *blockParser) codeBlock() {
+	m := fencePattern.FindStringSubmatch(p.lines[p.pos])
+	fence, language := m[1], m[2]
+	indent := len(p.lines[p.pos]) - len(strings.TrimLeft(p.lines[p.pos], " "))
+	p.pos++
+
+	var code []string
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		trimmed := strings.TrimLeft(line, " ")
+
+		if len(line)-len(trimmed) <= 3 && strings.HasPrefix(trimmed, fence) &&
+			strings.Trim(trimmed, fence[:1]+" \t") == "" {
+			p.pos++
+			break
+		}
+
+		// Lines lose up to the indentation of the opening fence.
+		for i := 0; i < indent && strings.HasPrefix(line, " "); i++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+
+	p.out.WriteString("<pre><code")
+	if language != "" && languagePattern.MatchString(language) {
+		p.out.WriteString(` class="language-` + html.EscapeString(language) + `"`)
+	}
+	p.out.WriteString(">")
+	for _, line := range code {
+		p.out.WriteString(html.EscapeString(line) + "\n")
+	}
+	p.out.WriteString("</code></pre>\n")
+}
+
+// list renders consecutive items of the same kind of list. Items are single
+// paragraphs; lines that don't start an item continue the previous one.
+func (p *blockParser) list() {
+	m := listItemPattern.FindStringSubmatch(p.lines[p.pos])
+	marker := m[1]
+	ordered := marker[0] >= '0' && marker[0] <= '9'
+	delimiter := marker[len(marker)-1]
+
+	if ordered {
+		start, _ := strconv.Atoi(marker[:len(marker)-1])
+		if start == 1 {
+			p.out.WriteString("<ol>\n")
+		} else {
+			p.out.WriteString(`<ol start="` + strconv.Itoa(start) + `">` + "\n")
+		}
+	} else {
+		p.out.WriteString("<ul>\n")
+	}
+
+	var item []string
+	flush := func() {
+		if item != nil {
+			p.out.WriteString("<li>" + renderInline(strings.Join(item, "\n")) + "</li>\n")
+		}
+		item = nil
+	}
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+
+		if isBlank(line) || fencePattern.MatchString(line) {
+			break
+		}
+
+		if m := listItemPattern.FindStringSubmatch(line); m != nil {
+			if m[1][len(m[1])-1] != delimiter || (m[1][0] >= '0' && m[1][0] <= '9') != ordered {
+				break
+			}
+			flush()
+			item = []string{strings.TrimSpace(line[len(m[0]):])}
+		} else {
+			item = append(item, strings.TrimSpace(line))
+		}
+
+		p.pos++
+	}
+
+	flush()
+
+	if ordered {
+		p.out.WriteString("</ol>\n")
+	} else {
+		p.out.WriteString("</ul>\n")
+	}
+}
+
+// paragraph renders lines up to the next blank line or block.
+func (p *blockParser) paragraph() {
+	var lines []string
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+
+		if isBlank(line) || fencePattern.MatchString(line) || (lines != nil && startsList(line)) {
+			break
+		}
+
+		lines = append(lines, strings.TrimLeft(line, " \t"))
+		p.pos++
+	}
+
+	p.out.WriteString("<p>" + renderInline(strings.Join(lines, "\n")) + "</p>\n")
+}
+
+// startsList reports whether line can interrupt a paragraph to start a
+// list. As in CommonMark, only non-empty items and ordered lists starting at
+// 1 can.
+func startsList(line string) bool {
+	m := listItemPattern.FindStringSubmatch(line)
+	if m == nil || isBlank(line[len(m[0]):]) {
+		return false
+	}
+
+	marker := m[1]
+	if marker[0] >= '0' && marker[0] <= '9' {
+		return marker[:len(marker)-1] == "1"
+	}
+
+	return true
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
@@ -0,0 +1,174 @@
+package markdown
+
+import (
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "plain text",
+			src:  "hello world",
+			want: "<p>hello world</p>",
+		},
+		{
+			name: "paragraphs and line breaks",
+			src:  "one\ntwo  \nthree\n\nfour",
+			want: "<p>one\ntwo<br>\nthree</p>\n<p>four</p>",
+		},
+		{
+			name: "emphasis",
+			src:  "*em* and **strong** and _em_ and __strong__",
+			want: "<p><em>em</em> and <strong>strong</strong> and <em>em</em> and <strong>strong</strong></p>",
+		},
+		{
+			name: "nested emphasis",
+			src:  "***both*** and *a **b** c*",
+			want: "<p><em><strong>both</strong></em> and <em>a <strong>b</strong> c</em></p>",
+		},
+		{
+			name: "intraword underscores",
+			src:  "snake_case_name and 2*3*4",
+			want: "<p>snake_case_name and 2<em>3</em>4</p>",
+		},
+		{
+			name: "unmatched delimiters",
+			src:  "a * b ** c _",
+			want: "<p>a * b ** c _</p>",
+		},
+		{
+			name: "escapes",
+			src:  `\*not em\* and \[x\]`,
+			want: "<p>*not em* and [x]</p>",
+		},
+		{
+			name: "code span",
+			src:  "use `a < b && *c*` here",
+			want: "<p>use <code>a &lt; b &amp;&amp; *c*</code> here</p>",
+		},
+		{
+			name: "fenced code block",
+			src:  "```go\nfunc main() {\n\tprintln(\"<hi>\")\n}\n```\nafter",
+			want: "<pre><code class=\"language-go\">func main() {\n\tprintln(&#34;&lt;hi&gt;&#34;)\n}\n</code></pre>\n<p>after</p>",
+		},
+		{
+			name: "unclosed fence",
+			src:  "~~~\ncode",
+			want: "<pre><code>code\n</code></pre>",
+		},
+		{
+			name: "bullet list",
+			src:  "- one\n- *two*\n  continued\n\ntext",
+			want: "<ul>\n<li>one</li>\n<li><em>two</em>\ncontinued</li>\n</ul>\n<p>text</p>",
+		},
+		{
+			name: "ordered list",
+			src:  "3. three\n4. four",
+			want: "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>",
+		},
+		{
+			name: "link",
+			src:  `[the *docs*](https://example.com/a_b "Title")`,
+			want: `<p><a href="https://example.com/a_b" rel="nofollow ugc">the <em>docs</em></a></p>`,
+		},
+		{
+			name: "autolink and bare URL",
+			src:  "<https://example.com> or see https://example.com/x_y_z.",
+			want: `<p><a href="https://example.com" rel="nofollow ugc">https://example.com</a> or see <a href="https://example.com/x_y_z" rel="nofollow ugc">https://example.com/x_y_z</a>.</p>`,
+		},
+		{
+			name: "unsafe link scheme",
+			src:  "[click](javascript:alert(1))",
+			want: "<p>click</p>",
+		},
+		{
+			name: "relative link",
+			src:  "[home](/home)",
+			want: "<p>home</p>",
+		},
+		{
+			name: "raw HTML is text",
+			src:  `<script>alert("x")</script> <img src=x onerror=alert(1)>`,
+			want: "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &lt;img src=x onerror=alert(1)&gt;</p>",
+		},
+		{
+			name: "hashtags and mentions",
+			src:  "#go with @gopher",
+			want: "<p>#go with @gopher</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src); got != tt.want {
+				t.Errorf("Render(%q)\n got: %q\nwant: %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "allowed elements",
+			src:  `<p><em>a</em><br><code class="language-go">b</code></p>`,
+			want: `<p><em>a</em><br><code class="language-go">b</code></p>`,
+		},
+		{
+			name: "disallowed elements and attributes",
+			src:  `<div onclick="x()"><p style="color:red">a<img src="x"></p></div>`,
+			want: `<p>a</p>`,
+		},
+		{
+			name: "dropped content",
+			src:  `<p>a<script>alert(1)</script>b</p>`,
+			want: `<p>ab</p>`,
+		},
+		{
+			name: "links",
+			src:  `<a href="https://example.com" target="_blank">a</a><a href="javascript:x()">b</a>`,
+			want: `<a href="https://example.com" rel="nofollow ugc">a</a>b`,
+		},
+		{
+			name: "unbalanced tags",
+			src:  `<p><em>a</p></strong>`,
+			want: `<p><em>a</em></p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.src); got != tt.want {
+				t.Errorf("Sanitize(%q)\n got: %q\nwant: %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	c := NewCache[int](2)
+
+	if got := c.Render(1, "*a*"); got != "<p><em>a</em></p>" {
+		t.Fatalf("got %q", got)
+	}
+
+	// A cached key is not rendered again, even if the source differs.
+	if got := c.Render(1, "*b*"); got != "<p><em>a</em></p>" {
+		t.Errorf("got %q, want the cached rendering", got)
+	}
+
+	c.Render(2, "b")
+	c.Render(3, "c")
+
+	if got := c.Render(1, "*b*"); got != "<p><em>b</em></p>" {
+		t.Errorf("got %q, want the least recently used entry evicted", got)
+	}
+}
@@ -0,0 +1,156 @@
+package markdown
+
+import (
+	"html"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// allowedAttrs lists the elements Sanitize keeps, with the attributes kept
+// on each.
+var allowedAttrs = map[string]map[string]bool{
+	"p":      {},
+	"br":     {},
+	"em":     {},
+	"strong": {},
+	"code":   {"class": true},
+	"pre":    {},
+	"ul":     {},
+	"ol":     {"start": true},
+	"li":     {},
+	"a":      {"href": true},
+}
+
+// droppedContent lists the elements whose content is removed along with
+// them, rather than kept as text.
+var droppedContent = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "template": true,
+}
+
+// Sanitize removes from src every element and attribute that is not in the
+// allowlist. Links keep only http, https and mailto URLs and are always
+// marked rel="nofollow ugc". Text is escaped again on the way out.
+func Sanitize(src string) string {
+	var b strings.Builder
+
+	z := xhtml.NewTokenizer(strings.NewReader(src))
+	dropping := 0
+	// open counts the kept elements still open, so stray end tags are
+	// dropped and the output is always balanced.
+	open := map[string]int{}
+	var stack []string
+
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+
+		token := z.Token()
+
+		switch tt {
+		case xhtml.TextToken:
+			if dropping == 0 {
+				b.WriteString(html.EscapeString(token.Data))
+			}
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if droppedContent[token.Data] {
+				if tt == xhtml.StartTagToken {
+					dropping++
+				}
+				continue
+			}
+
+			attrs, ok := allowedAttrs[token.Data]
+			if !ok || dropping > 0 {
+				continue
+			}
+
+			tag, ok := sanitizeTag(token, attrs)
+			if !ok {
+				continue
+			}
+			b.WriteString(tag)
+
+			if token.Data != "br" && tt == xhtml.StartTagToken {
+				open[token.Data]++
+				stack = append(stack, token.Data)
+			}
+		case xhtml.EndTagToken:
+			if droppedContent[token.Data] {
+				if dropping > 0 {
+					dropping--
+				}
+				continue
+			}
+
+			if open[token.Data] == 0 || dropping > 0 {
+				continue
+			}
+
+			// Close whatever was left open inside the element too.
+			for len(stack) > 0 {
+				name := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				open[name]--
+				b.WriteString("</" + name + ">")
+				if name == token.Data {
+					break
+				}
+			}
+		}
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteString("</" + stack[i] + ">")
+	}
+
+	return b.String()
+}
+
+// sanitizeTag renders the start tag of token with only the allowed
+// attributes. It reports false for links without a safe URL, which are
+// dropped while their text is kept.
+func sanitizeTag(token xhtml.Token, allowed map[string]bool) (string, bool) {
+	var b strings.Builder
+	b.WriteString("<" + token.Data)
+
+	for _, attr := range token.Attr {
+		if !allowed[attr.Key] || attr.Namespace != "" {
+			continue
+		}
+
+		value := attr.Val
+
+		switch {
+		case token.Data == "a" && attr.Key == "href":
+			href, ok := safeURL(value)
+			if !ok {
+				continue
+			}
+			value = href
+		case token.Data == "code" && attr.Key == "class":
+			if !strings.HasPrefix(value, "language-") || !languagePattern.MatchString(value[len("language-"):]) {
+				continue
+			}
+		case token.Data == "ol" && attr.Key == "start":
+			if strings.Trim(value, "0123456789") != "" || value == "" {
+				continue
+			}
+		}
+
+		b.WriteString(" " + attr.Key + `="` + html.EscapeString(value) + `"`)
+	}
+
+	if token.Data == "a" {
+		if !strings.Contains(b.String(), " href=") {
+			return "", false
+		}
+		b.WriteString(` rel="nofollow ugc"`)
+	}
+
+	b.WriteString(">")
+
+	return b.String(), true
+}
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DailyViews is the number of distinct users who saw a post on a day, in
+// UTC.
+type DailyViews struct {
+	PostID int64
+	Day    string
+	Views  int64
+}
+
+// Analytics sums up how the posts of a user performed over a period.
+type Analytics struct {
+	From  string          `json:"from"`
+	To    string          `json:"to"`
+	Days  []DayAnalytics  `json:"days"`
+	Posts []PostAnalytics `json:"posts"`
+}
+
+// DayAnalytics counts the activity on the posts of a user on a day, and the
+// users who started following them. Reactions and comments of the user on
+// their own posts are not counted.
+type DayAnalytics struct {
+	Day          string `json:"day"`
+	Views        int64  `json:"views"`
+	Reactions    int64  `json:"reactions"`
+	Comments     int64  `json:"comments"`
+	NewFollowers int64  `json:"new_followers"`
+}
+
+type PostAnalytics struct {
+	PostID    int64  `json:"post_id"`
+	Title     string `json:"title"`
+	CreatedAt string `json:"created_at"`
+	Views     int64  `json:"views"`
+	Reactions int64  `json:"reactions"`
+	Comments  int64  `json:"comments"`
+}
+
+type AnalyticsStore struct {
+	db *sql.DB
+}
+
+func NewAnalyticsStore(db *sql.DB) *AnalyticsStore {
+	return &AnalyticsStore{db: db}
+}
+
+// AddViews adds views to the daily counts. Views of posts that have been
+// purged in the meantime are dropped.
+func (s *AnalyticsStore) AddViews(ctx context.Context, views []DailyViews) error {
+	if len(views) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO post_views_daily (post_id, day, views)
+		SELECT v.post_id, v.day, v.views
+		FROM unnest($1::bigint[], $2::date[], $3::bigint[]) AS v(post_id, day, views)
+		JOIN posts p ON p.id = v.post_id
+		ON CONFLICT (post_id, day) DO UPDATE SET views = post_views_daily.views + EXCLUDED.views
+	`
+
+	postIds := make([]int64, len(views))
+	days := make([]string, len(views))
+	counts := make([]int64, len(views))
+	for i, v := range views {
+		postIds[i], days[i], counts[i] = v.PostID, v.Day, v.Views
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, pq.Array(postIds), pq.Array(days), pq.Array(counts))
+
+	return err
+}
+
+// Get returns the analytics of the posts of userId from the start of the
+// day from, in UTC, up to now. Posts are listed by views, then reactions
+// and comments, up to postsLimit.
+func (s *AnalyticsStore) Get(ctx context.Context, userId int64, from time.Time, postsLimit int) (*Analytics, error) {
+	from = from.UTC()
+
+	analytics := &Analytics{
+		From: from.Format(time.DateOnly),
+		To:   time.Now().UTC().Format(time.DateOnly),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	days, err := s.getDays(ctx, userId, analytics.From, analytics.To)
+	if err != nil {
+		return nil, err
+	}
+	analytics.Days = days
+
+	posts, err := s.getPosts(ctx, userId, analytics.From, postsLimit)
+	if err != nil {
+		return nil, err
+	}
+	analytics.Posts = posts
+
+	return analytics, nil
+}
+
+func (s *AnalyticsStore) getDays(ctx context.Context, userId int64, from, to string) ([]DayAnalytics, error) {
+	query := `
+		WITH
+		views AS (
+			SELECT pv.day, SUM(pv.views) AS n
+			FROM post_views_daily pv
+			JOIN posts p ON p.id = pv.post_id
+			WHERE p.user_id = $1 AND pv.day >= $2::date
+			GROUP BY pv.day
+		),
+		reactions AS (
+			SELECT (r.created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS n
+			FROM post_reactions r
+			JOIN posts p ON p.id = r.post_id
+			WHERE p.user_id = $1 AND r.user_id <> $1 AND r.created_at >= $2::date::timestamp AT TIME ZONE 'UTC'
+			GROUP BY 1
+		),
+		comments AS (
+			SELECT (c.created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS n
+			FROM comments c
+			JOIN posts p ON p.id = c.post_id
+			WHERE
+				p.user_id = $1 AND c.user_id <> $1 AND c.deleted_at IS NULL AND
+				c.created_at >= $2::date::timestamp AT TIME ZONE 'UTC'
+			GROUP BY 1
+		),
+		followers AS (
+			SELECT (f.created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS n
+			FROM followers f
+			WHERE f.follower_id = $1 AND f.created_at >= $2::date::timestamp AT TIME ZONE 'UTC'
+			GROUP BY 1
+		)
+		SELECT
+			to_char(d.day, 'YYYY-MM-DD'),
+			COALESCE(v.n, 0), COALESCE(r.n, 0), COALESCE(c.n, 0), COALESCE(f.n, 0)
+		FROM (SELECT generate_series($2::date::timestamp, $3::date::timestamp, INTERVAL '1 day')::date AS day) d
+		LEFT JOIN views v ON v.day = d.day
+		LEFT JOIN reactions r ON r.day = d.day
+		LEFT JOIN comments c ON c.day = d.day
+		LEFT JOIN followers f ON f.day = d.day
+		ORDER BY d.day
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []DayAnalytics{}
+	for rows.Next() {
+		var d DayAnalytics
+		if err := rows.Scan(&d.Day, &d.Views, &d.Reactions, &d.Comments, &d.NewFollowers); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+
+	return days, rows.Err()
+}
+
+func (s *AnalyticsStore) getPosts(ctx context.Context, userId int64, from string, limit int) ([]PostAnalytics, error) {
+	query := `
+		SELECT p.id, p.title, p.created_at, a.views, a.reactions, a.comments
+		FROM posts p
+		CROSS JOIN LATERAL (
+			SELECT
+				COALESCE((
+					SELECT SUM(pv.views) FROM post_views_daily pv
+					WHERE pv.post_id = p.id AND pv.day >= $2::date
+				), 0) AS views,
+				(
+					SELECT COUNT(*) FROM post_reactions r
+					WHERE r.post_id = p.id AND r.user_id <> p.user_id AND r.created_at >= $2::date::timestamp AT TIME ZONE 'UTC'
+				) AS reactions,
+				(
+					SELECT COUNT(*) FROM comments c
+					WHERE
+						c.post_id = p.id AND c.user_id <> p.user_id AND c.deleted_at IS NULL AND
+						c.created_at >= $2::date::timestamp AT TIME ZONE 'UTC'
+				) AS comments
+		) a
+		WHERE p.user_id = $1 AND p.deleted_at IS NULL AND p.status = 'published'
+		ORDER BY a.views DESC, a.reactions DESC, a.comments DESC, p.created_at DESC, p.id DESC
+		LIMIT $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userId, from, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []PostAnalytics{}
+	for rows.Next() {
+		var p PostAnalytics
+		if err := rows.Scan(&p.PostID, &p.Title, &p.CreatedAt, &p.Views, &p.Reactions, &p.Comments); err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+
+	return posts, rows.Err()
+}
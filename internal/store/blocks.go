@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type BlocksStore struct {
+	db *sql.DB
+}
+
+func NewBlocksStore(db *sql.DB) *BlocksStore {
+	return &BlocksStore{db: db}
+}
+
+// Block makes userId block blockedId, which hides the posts of userId from
+// blockedId, see visibleTo. Follows between them are dropped. Blocking twice
+// is a no-op.
+func (s *BlocksStore) Block(ctx context.Context, userId, blockedId int64) error {
+	blockQuery := `
+		INSERT INTO user_blocks (user_id, blocked_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, blocked_id) DO NOTHING
+	`
+
+	unfollowQuery := `
+		DELETE FROM followers
+		WHERE (user_id = $1 AND follower_id = $2) OR (user_id = $2 AND follower_id = $1)
+	`
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if _, err := tx.ExecContext(ctx, blockQuery, userId, blockedId); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, unfollowQuery, userId, blockedId)
+
+		return err
+	})
+}
+
+// Unblock undoes a block. Unblocking a user who isn't blocked is a no-op.
+func (s *BlocksStore) Unblock(ctx context.Context, userId, blockedId int64) error {
+	query := `DELETE FROM user_blocks WHERE user_id = $1 AND blocked_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userId, blockedId)
+
+	return err
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestBlocks(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	blocked := createTestUser(t, db, "blocked", "")
+	stranger := createTestUser(t, db, "stranger", "")
+
+	follow(t, s, blocked.ID, author.ID)
+	follow(t, s, author.ID, blocked.ID)
+
+	pinned := createTestPost(t, s, &Post{UserID: author.ID})
+	post := createTestPost(t, s, &Post{UserID: author.ID})
+
+	if err := s.Pins.Pin(ctx, author.ID, pinned.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	listed := func(t *testing.T, viewer *User) []int64 {
+		t.Helper()
+
+		page, err := s.Posts.GetByUser(ctx, author.ID, viewer.ID, PaginatedPostsQuery{Limit: 10, Tags: []string{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return postIDs(page.Posts)
+	}
+
+	all := []int64{pinned.ID, post.ID}
+
+	if err := s.Blocks.Block(ctx, author.ID, blocked.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should hide the posts of the blocker", func(t *testing.T) {
+		if got := listed(t, blocked); len(got) != 0 {
+			t.Errorf("expected no posts; got %v", got)
+		}
+
+		visible, err := s.Posts.IsVisibleTo(ctx, post.ID, blocked.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if visible {
+			t.Error("expected the post to be hidden")
+		}
+	})
+
+	t.Run("should not hide the posts from others", func(t *testing.T) {
+		if got := listed(t, stranger); !slices.Equal(got, all) {
+			t.Errorf("expected %v; got %v", all, got)
+		}
+	})
+
+	t.Run("should drop follows both ways", func(t *testing.T) {
+		n := countTest(t, db, `
+			SELECT COUNT(*) FROM followers
+			WHERE user_id IN ($1, $2) AND follower_id IN ($1, $2)
+		`, author.ID, blocked.ID)
+
+		if n != 0 {
+			t.Errorf("expected no follows; got %d", n)
+		}
+	})
+
+	t.Run("should be a no-op to block twice", func(t *testing.T) {
+		if err := s.Blocks.Block(ctx, author.ID, blocked.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("should not find unknown users", func(t *testing.T) {
+		if err := s.Blocks.Block(ctx, author.ID, -1); err != ErrNotFound {
+			t.Errorf("expected ErrNotFound; got %v", err)
+		}
+	})
+
+	t.Run("should show the posts again once unblocked", func(t *testing.T) {
+		if err := s.Blocks.Unblock(ctx, author.ID, blocked.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := listed(t, blocked); !slices.Equal(got, all) {
+			t.Errorf("expected %v; got %v", all, got)
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const DefaultBookmarkCollection = "Saved"
+
+type BookmarkCollection struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"is_default"`
+	CreatedAt string `json:"created_at"`
+}
+
+type BookmarksStore struct {
+	db *sql.DB
+}
+
+func NewBookmarksStore(db *sql.DB) *BookmarksStore {
+	return &BookmarksStore{db: db}
+}
+
+// GetCollections returns the collections of userId, creating the default one
+// on first use.
+func (s *BookmarksStore) GetCollections(ctx context.Context, userId int64) ([]BookmarkCollection, error) {
+	if _, err := s.defaultCollection(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, user_id, name, is_default, created_at
+		FROM bookmark_collections
+		WHERE user_id = $1
+		ORDER BY is_default DESC, created_at, id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []BookmarkCollection{}
+
+	for rows.Next() {
+		c := BookmarkCollection{}
+
+		if err := rows.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.Name,
+			&c.IsDefault,
+			&c.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		collections = append(collections, c)
+	}
+
+	return collections, rows.Err()
+}
+
+func (s *BookmarksStore) CreateCollection(ctx context.Context, collection *BookmarkCollection) error {
+	// Creating the default collection first keeps its name reserved.
+	if _, err := s.defaultCollection(ctx, collection.UserID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO bookmark_collections (user_id, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, collection.UserID, collection.Name).Scan(
+		&collection.ID,
+		&collection.CreatedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+// DeleteCollection deletes a collection of userId together with its
+// bookmarks. The default collection cannot be deleted.
+func (s *BookmarksStore) DeleteCollection(ctx context.Context, collectionId, userId int64) error {
+	query := `
+		DELETE FROM bookmark_collections
+		WHERE id = $1 AND user_id = $2 AND NOT is_default
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, collectionId, userId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Add bookmarks postId in a collection of userId. A zero collectionId stands
+// for the default collection. Adding a post twice is a no-op.
+func (s *BookmarksStore) Add(ctx context.Context, userId, collectionId, postId int64) error {
+	collectionId, err := s.resolveCollection(ctx, userId, collectionId)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO bookmarks (collection_id, post_id)
+		VALUES ($1, $2)
+		ON CONFLICT (collection_id, post_id) DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, query, collectionId, postId); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Remove removes postId from a collection of userId. A zero collectionId
+// stands for the default collection. Removing a missing bookmark is a no-op.
+func (s *BookmarksStore) Remove(ctx context.Context, userId, collectionId, postId int64) error {
+	collectionId, err := s.resolveCollection(ctx, userId, collectionId)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM bookmarks WHERE collection_id = $1 AND post_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctx, query, collectionId, postId)
+
+	return err
+}
+
+// GetPosts lists the posts in a collection of userId, most recently saved
+// first. A zero collectionId stands for the default collection. Posts the
+// user can no longer see are left out.
+func (s *BookmarksStore) GetPosts(ctx context.Context, userId, collectionId int64, ppq PaginatedPostsQuery) (*PostsPage, error) {
+	collectionId, err := s.resolveCollection(ctx, userId, collectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + feedSelect{viewer: "$1", sortKey: "b.created_at, b.post_id"}.columns() + `
+		FROM bookmarks b
+		JOIN posts p ON p.id = b.post_id
+		JOIN users u ON u.id = p.user_id
+		WHERE
+			b.collection_id = $2 AND
+			` + visibleTo("p", "$1") + ` AND
+			($3::timestamptz IS NULL OR (b.created_at, b.post_id) < ($3, $4))
+		ORDER BY b.created_at DESC, b.post_id DESC
+		LIMIT $5
+	`
+
+	var cursorCreatedAt any
+	var cursorID int64
+	if ppq.Cursor != nil {
+		cursorCreatedAt = ppq.Cursor.CreatedAt
+		cursorID = ppq.Cursor.ID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userId, collectionId, cursorCreatedAt, cursorID, ppq.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := scanPostsForFeed(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPostsPage(posts, ppq.Limit), nil
+}
+
+func (s *BookmarksStore) resolveCollection(ctx context.Context, userId, collectionId int64) (int64, error) {
+	if collectionId == 0 {
+		return s.defaultCollection(ctx, userId)
+	}
+
+	query := `SELECT id FROM bookmark_collections WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, collectionId, userId).Scan(&collectionId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, ErrNotFound
+		default:
+			return 0, err
+		}
+	}
+
+	return collectionId, nil
+}
+
+// defaultCollection returns the default collection of userId, creating it
+// on first use. A concurrent first use makes the insert a no-op, and the
+// collection it created is selected again.
+func (s *BookmarksStore) defaultCollection(ctx context.Context, userId int64) (int64, error) {
+	selectQuery := `SELECT id FROM bookmark_collections WHERE user_id = $1 AND is_default`
+
+	insertQuery := `
+		INSERT INTO bookmark_collections (user_id, name, is_default)
+		VALUES ($1, $2, TRUE)
+		ON CONFLICT (user_id) WHERE is_default DO NOTHING
+		RETURNING id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var id int64
+
+	err := s.db.QueryRowContext(ctx, selectQuery, userId).Scan(&id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+
+	err = s.db.QueryRowContext(ctx, insertQuery, userId, DefaultBookmarkCollection).Scan(&id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+
+	err = s.db.QueryRowContext(ctx, selectQuery, userId).Scan(&id)
+
+	return id, err
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestBookmarksGetPosts(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	reader := createTestUser(t, db, "reader", "")
+
+	follow(t, s, reader.ID, author.ID)
+
+	public := createTestPost(t, s, &Post{UserID: author.ID})
+	followersOnly := createTestPost(t, s, &Post{UserID: author.ID, Visibility: VisibilityFollowers})
+	deleted := createTestPost(t, s, &Post{UserID: author.ID})
+
+	for _, post := range []*Post{public, followersOnly, deleted} {
+		if err := s.Bookmarks.Add(ctx, reader.ID, 0, post.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(t *testing.T) []int64 {
+		t.Helper()
+
+		page, err := s.Bookmarks.GetPosts(ctx, reader.ID, 0, PaginatedPostsQuery{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return postIDs(page.Posts)
+	}
+
+	t.Run("should keep a single default collection", func(t *testing.T) {
+		collections, err := s.Bookmarks.GetCollections(ctx, reader.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(collections) != 1 || !collections[0].IsDefault {
+			t.Errorf("expected the default collection only; got %+v", collections)
+		}
+	})
+
+	t.Run("should list visible bookmarks", func(t *testing.T) {
+		expected := []int64{deleted.ID, followersOnly.ID, public.ID}
+		if got := list(t); !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+	})
+
+	t.Run("should leave out deleted posts", func(t *testing.T) {
+		if err := s.Posts.DeleteById(ctx, deleted.ID, deleted.Version, author.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []int64{followersOnly.ID, public.ID}
+		if got := list(t); !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+	})
+
+	t.Run("should leave out posts no longer visible", func(t *testing.T) {
+		if err := s.Followers.Unfollow(ctx, reader.ID, author.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []int64{public.ID}
+		if got := list(t); !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+	})
+}
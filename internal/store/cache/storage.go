@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"time"
 
 	"github.com/DenysBahachuk/gopher_social/internal/store"
 	"github.com/go-redis/redis/v8"
@@ -13,10 +14,23 @@ type Storage struct {
 		Set(context.Context, *store.User) error
 		Delete(context.Context, int64) error
 	}
+	Trending interface {
+		SetPosts(context.Context, []store.TrendingPost) error
+		SetTags(context.Context, []store.TrendingTag) error
+		GetPosts(context.Context, int) ([]store.TrendingPost, error)
+		GetTags(context.Context, int) ([]store.TrendingTag, error)
+	}
+	Views interface {
+		Record(context.Context, int64, []int64, time.Time) error
+		TakePending(context.Context) ([]store.DailyViews, bool, error)
+		AckPending(context.Context) error
+	}
 }
 
 func NewRedisStorage(redisDb *redis.Client) Storage {
 	return Storage{
-		Users: &UsersStore{redisDb: redisDb},
+		Users:    &UsersStore{redisDb: redisDb},
+		Trending: &TrendingStore{redisDb: redisDb},
+		Views:    &ViewsStore{redisDb: redisDb},
 	}
 }
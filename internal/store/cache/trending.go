@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	trendingPostsKey = "trending_posts"
+	trendingTagsKey  = "trending_tags"
+)
+
+// TrendingExpTime bounds how long trending lists outlive the job that
+// refreshes them.
+const TrendingExpTime = time.Hour
+
+// TrendingStore keeps the trending lists in sorted sets scored by trending
+// score.
+type TrendingStore struct {
+	redisDb *redis.Client
+}
+
+func (s *TrendingStore) SetPosts(ctx context.Context, posts []store.TrendingPost) error {
+	members := make([]*redis.Z, len(posts))
+	for i, p := range posts {
+		members[i] = &redis.Z{Score: p.Score, Member: strconv.FormatInt(p.PostID, 10)}
+	}
+
+	return s.replace(ctx, trendingPostsKey, members)
+}
+
+func (s *TrendingStore) SetTags(ctx context.Context, tags []store.TrendingTag) error {
+	members := make([]*redis.Z, len(tags))
+	for i, t := range tags {
+		members[i] = &redis.Z{Score: t.Score, Member: t.Tag}
+	}
+
+	return s.replace(ctx, trendingTagsKey, members)
+}
+
+// GetPosts returns the top limit trending posts, or none when the list
+// hasn't been set.
+func (s *TrendingStore) GetPosts(ctx context.Context, limit int) ([]store.TrendingPost, error) {
+	members, err := s.redisDb.ZRevRangeWithScores(ctx, trendingPostsKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]store.TrendingPost, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseInt(m.Member.(string), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, store.TrendingPost{PostID: id, Score: m.Score})
+	}
+
+	return posts, nil
+}
+
+// GetTags is the GetPosts of tags.
+func (s *TrendingStore) GetTags(ctx context.Context, limit int) ([]store.TrendingTag, error) {
+	members, err := s.redisDb.ZRevRangeWithScores(ctx, trendingTagsKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]store.TrendingTag, 0, len(members))
+	for _, m := range members {
+		tags = append(tags, store.TrendingTag{Tag: m.Member.(string), Score: m.Score})
+	}
+
+	return tags, nil
+}
+
+// replace swaps the contents of key for members at once, so readers never
+// see a partially written list.
+func (s *TrendingStore) replace(ctx context.Context, key string, members []*redis.Z) error {
+	if len(members) == 0 {
+		return s.redisDb.Del(ctx, key).Err()
+	}
+
+	tmp := key + "_next"
+
+	_, err := s.redisDb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, tmp)
+		pipe.ZAdd(ctx, tmp, members...)
+		pipe.Rename(ctx, tmp, key)
+		pipe.Expire(ctx, key, TrendingExpTime)
+		return nil
+	})
+
+	return err
+}
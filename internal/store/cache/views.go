@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// pendingViewsKey is a hash of view counts not yet flushed to the
+	// database, keyed by "<post id>:<day>".
+	pendingViewsKey = "post_views_pending"
+	// flushingViewsKey holds the counts being flushed. It is left in place
+	// when a flush fails, to be picked up by the next one.
+	flushingViewsKey  = "post_views_flushing"
+	flushViewsLockKey = "post_views_flush_lock"
+)
+
+// ViewsSeenExpTime is how long the viewers of a post are remembered for
+// deduplication. Views are deduplicated per day, so it only needs to outlast
+// the day.
+const ViewsSeenExpTime = time.Hour * 25
+
+// flushViewsLockTime bounds how long a flush may take before another one can
+// start.
+const flushViewsLockTime = time.Minute * 5
+
+// recordViews counts a view of each post in ARGV[4:] by the viewer in
+// ARGV[2] on the day in ARGV[1], unless the viewer already saw it that day.
+var recordViews = redis.NewScript(`
+	for i = 4, #ARGV do
+		local seen = 'post_views_seen:' .. ARGV[1] .. ':' .. ARGV[i]
+		if redis.call('SADD', seen, ARGV[2]) == 1 then
+			redis.call('EXPIRE', seen, ARGV[3])
+			redis.call('HINCRBY', KEYS[1], ARGV[i] .. ':' .. ARGV[1], 1)
+		end
+	end
+	return 0
+`)
+
+// ViewsStore buffers post views until they are flushed to the database.
+type ViewsStore struct {
+	redisDb *redis.Client
+}
+
+// Record counts a view of every post in postIds by viewerId at time at,
+// once per viewer, post and day.
+func (s *ViewsStore) Record(ctx context.Context, viewerId int64, postIds []int64, at time.Time) error {
+	if len(postIds) == 0 {
+		return nil
+	}
+
+	args := []any{
+		at.UTC().Format(time.DateOnly),
+		viewerId,
+		int(ViewsSeenExpTime.Seconds()),
+	}
+	for _, id := range postIds {
+		args = append(args, id)
+	}
+
+	return recordViews.Run(ctx, s.redisDb, []string{pendingViewsKey}, args...).Err()
+}
+
+// TakePending returns the views recorded since the last flush, which must
+// be acknowledged with AckPending once saved. Only one flush runs at a time:
+// ok is false when another one is in progress. Views of a flush that failed
+// are returned again, so they are counted at least once.
+func (s *ViewsStore) TakePending(ctx context.Context) (views []store.DailyViews, ok bool, err error) {
+	locked, err := s.redisDb.SetNX(ctx, flushViewsLockKey, 1, flushViewsLockTime).Result()
+	if err != nil || !locked {
+		return nil, false, err
+	}
+
+	exists, err := s.redisDb.Exists(ctx, flushingViewsKey).Result()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if exists == 0 {
+		err := s.redisDb.Rename(ctx, pendingViewsKey, flushingViewsKey).Err()
+		if err != nil {
+			// Nothing was viewed since the last flush.
+			if strings.Contains(err.Error(), "no such key") {
+				return nil, true, nil
+			}
+			return nil, false, err
+		}
+	}
+
+	fields, err := s.redisDb.HGetAll(ctx, flushingViewsKey).Result()
+	if err != nil {
+		return nil, false, err
+	}
+
+	views = make([]store.DailyViews, 0, len(fields))
+	for field, count := range fields {
+		postId, day, found := strings.Cut(field, ":")
+		if !found {
+			return nil, false, errors.New("malformed pending views field " + field)
+		}
+
+		id, err := strconv.ParseInt(postId, 10, 64)
+		if err != nil {
+			return nil, false, err
+		}
+
+		n, err := strconv.ParseInt(count, 10, 64)
+		if err != nil {
+			return nil, false, err
+		}
+
+		views = append(views, store.DailyViews{PostID: id, Day: day, Views: n})
+	}
+
+	return views, true, nil
+}
+
+// AckPending drops the views returned by TakePending and lets the next
+// flush start.
+func (s *ViewsStore) AckPending(ctx context.Context) error {
+	return s.redisDb.Del(ctx, flushingViewsKey, flushViewsLockKey).Err()
+}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DenysBahachuk/gopher_social/internal/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func newTestViewsStore(t *testing.T) (*ViewsStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return &ViewsStore{redisDb: rdb}, mr
+}
+
+// takePending takes and acknowledges the pending views, sorted by post and
+// day.
+func takePending(t *testing.T, s *ViewsStore) []store.DailyViews {
+	t.Helper()
+	ctx := context.Background()
+
+	views, ok, err := s.TakePending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected to take the pending views")
+	}
+
+	if err := s.AckPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	slices.SortFunc(views, func(a, b store.DailyViews) int {
+		return cmp.Or(cmp.Compare(a.PostID, b.PostID), strings.Compare(a.Day, b.Day))
+	})
+
+	return views
+}
+
+func TestViewsRecord(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should count a viewer once per post and day", func(t *testing.T) {
+		s, _ := newTestViewsStore(t)
+
+		for _, record := range []struct {
+			viewerId int64
+			postIds  []int64
+			at       time.Time
+		}{
+			{1, []int64{10, 20}, day},
+			{1, []int64{10}, day.Add(time.Hour)},
+			{2, []int64{10}, day},
+			{1, []int64{10}, day.Add(24 * time.Hour)},
+		} {
+			if err := s.Record(ctx, record.viewerId, record.postIds, record.at); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		expected := []store.DailyViews{
+			{PostID: 10, Day: "2024-05-01", Views: 2},
+			{PostID: 10, Day: "2024-05-02", Views: 1},
+			{PostID: 20, Day: "2024-05-01", Views: 1},
+		}
+
+		if got := takePending(t, s); !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+	})
+
+	t.Run("should forget viewers after a day", func(t *testing.T) {
+		s, mr := newTestViewsStore(t)
+
+		if err := s.Record(ctx, 1, []int64{10}, day); err != nil {
+			t.Fatal(err)
+		}
+
+		if ttl := mr.TTL("post_views_seen:2024-05-01:10"); ttl != ViewsSeenExpTime {
+			t.Errorf("expected the viewers to expire in %s; got %s", ViewsSeenExpTime, ttl)
+		}
+	})
+
+	t.Run("should not count views twice across flushes", func(t *testing.T) {
+		s, _ := newTestViewsStore(t)
+
+		if err := s.Record(ctx, 1, []int64{10}, day); err != nil {
+			t.Fatal(err)
+		}
+		takePending(t, s)
+
+		if err := s.Record(ctx, 1, []int64{10}, day); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := takePending(t, s); len(got) != 0 {
+			t.Errorf("expected no new views; got %v", got)
+		}
+	})
+}
+
+func TestViewsTakePending(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should run one flush at a time", func(t *testing.T) {
+		s, _ := newTestViewsStore(t)
+
+		if err := s.Record(ctx, 1, []int64{10}, day); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok, err := s.TakePending(ctx); err != nil || !ok {
+			t.Fatalf("expected to take the pending views; got %t, %v", ok, err)
+		}
+
+		if _, ok, err := s.TakePending(ctx); err != nil || ok {
+			t.Errorf("expected the flush to be locked; got %t, %v", ok, err)
+		}
+	})
+
+	t.Run("should return the views of a failed flush again", func(t *testing.T) {
+		s, mr := newTestViewsStore(t)
+
+		if err := s.Record(ctx, 1, []int64{10}, day); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := s.TakePending(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		// The flush fails without acknowledging, and its lock expires.
+		mr.FastForward(flushViewsLockTime)
+
+		if err := s.Record(ctx, 2, []int64{10}, day); err != nil {
+			t.Fatal(err)
+		}
+
+		// Each flush takes one view: first the one of viewer 1 left by the
+		// failed flush, then the one of viewer 2 recorded since.
+		expected := []store.DailyViews{{PostID: 10, Day: "2024-05-01", Views: 1}}
+		for _, flush := range []string{"failed", "next"} {
+			if got := takePending(t, s); !slices.Equal(got, expected) {
+				t.Errorf("%s flush: expected %v; got %v", flush, expected, got)
+			}
+		}
+	})
+
+	t.Run("should take nothing when nothing was viewed", func(t *testing.T) {
+		s, _ := newTestViewsStore(t)
+
+		if got := takePending(t, s); len(got) != 0 {
+			t.Errorf("expected no views; got %v", got)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type CommentReactionsStore struct {
+	db *sql.DB
+}
+
+func NewCommentReactionsStore(db *sql.DB) *CommentReactionsStore {
+	return &CommentReactionsStore{db: db}
+}
+
+// Set records kind as the reaction of userId to commentId, replacing any
+// previous reaction of that user. Setting the same kind twice is a no-op.
+func (s *CommentReactionsStore) Set(ctx context.Context, commentId, userId int64, kind string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		// Locking the comment serializes reactions to it, so the previous
+		// kind read below cannot change before the counters are adjusted.
+		if err := lockReactable(ctx, tx, "comments", commentId); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		query := `SELECT kind FROM comment_reactions WHERE comment_id = $1 AND user_id = $2`
+
+		var prev string
+		err := tx.QueryRowContext(ctx, query, commentId, userId).Scan(&prev)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if prev == kind {
+			return nil
+		}
+
+		query = `
+			INSERT INTO comment_reactions (comment_id, user_id, kind)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (comment_id, user_id) DO UPDATE
+			SET kind = EXCLUDED.kind, created_at = NOW()
+		`
+
+		if _, err := tx.ExecContext(ctx, query, commentId, userId, kind); err != nil {
+			return err
+		}
+
+		if prev != "" {
+			if err := adjustReactionCount(ctx, tx, "comments", "reactions_count", commentId, prev, -1); err != nil {
+				return err
+			}
+		}
+
+		return adjustReactionCount(ctx, tx, "comments", "reactions_count", commentId, kind, 1)
+	})
+}
+
+// Delete removes the reaction of userId to commentId. Deleting a reaction
+// that does not exist is a no-op.
+func (s *CommentReactionsStore) Delete(ctx context.Context, commentId, userId int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		// The comment is locked before the reaction, in the same order as
+		// Set, so the two can't deadlock.
+		if err := lockReactable(ctx, tx, "comments", commentId); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		query := `
+			DELETE FROM comment_reactions
+			WHERE comment_id = $1 AND user_id = $2
+			RETURNING kind
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var kind string
+
+		err := tx.QueryRowContext(ctx, query, commentId, userId).Scan(&kind)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return nil
+			default:
+				return err
+			}
+		}
+
+		return adjustReactionCount(ctx, tx, "comments", "reactions_count", commentId, kind, -1)
+	})
+}
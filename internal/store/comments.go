@@ -3,6 +3,12 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/lib/pq"
 )
 
 type Comment struct {
@@ -12,6 +18,146 @@ type Comment struct {
 	Content   string `json:"content"`
 	CreatedAt string `json:"created_at"`
 	User      User   `json:"user"`
+	// Entities are the hashtags and mentions in Content.
+	Entities Entities `json:"entities"`
+
+	// Edited is set once the comment has been changed after it was posted,
+	// last at EditedAt.
+	Edited   bool    `json:"edited"`
+	EditedAt *string `json:"edited_at,omitempty"`
+
+	// ParentCommentID is the comment this one replies to, nil on top-level
+	// comments, which are at Depth 0.
+	ParentCommentID *int64 `json:"parent_comment_id"`
+	Depth           int    `json:"depth"`
+	RepliesCount    int    `json:"replies_count"`
+	// Replies are only set on comments listed as a tree, oldest first.
+	// RepliesNextCursor continues them when they were cut at the limit.
+	Replies           []*Comment `json:"replies,omitempty"`
+	RepliesNextCursor string     `json:"replies_next_cursor,omitempty"`
+	// Tombstone is set on deleted comments, and on comments hidden from the
+	// viewer, kept in listings because they have replies. Their content and
+	// author are left out.
+	Tombstone bool `json:"tombstone,omitempty"`
+	// Hidden is set on comments the author of the post hid from others. They
+	// are only shown to the authors of the comment and of the post.
+	Hidden bool `json:"hidden,omitempty"`
+
+	ReactionCounts ReactionCounts `json:"reaction_counts"`
+	MyReaction     string         `json:"my_reaction,omitempty"`
+	// Highlighted is set on the comment the author of the post highlighted,
+	// which is listed first.
+	Highlighted bool `json:"highlighted,omitempty"`
+
+	// score ranks the comment in "top" order.
+	score float64
+}
+
+// Comment listing modes.
+const (
+	// CommentsFlat lists comments in thread order: each comment is followed
+	// by its replies, and its Depth tells how far to indent it.
+	CommentsFlat = "flat"
+	// CommentsTree nests the replies of comments in their Replies.
+	CommentsTree = "tree"
+)
+
+// Comment orders. Listings default to CommentsNew for top-level comments and
+// to CommentsOld for replies.
+const (
+	CommentsTop = "top"
+	CommentsNew = "new"
+	CommentsOld = "old"
+)
+
+// maxCommentNodes bounds the size of a listing. Levels of replies beyond it
+// are left out, to be fetched comment by comment.
+const maxCommentNodes = 500
+
+// CommentsQuery selects a page of the top-level comments of a post, or of
+// the replies to ParentID, in Sort order, with Depth levels of their replies
+// and at most Replies replies per comment.
+type CommentsQuery struct {
+	Limit    int     `json:"limit" validate:"gte=1,lte=50"`
+	Cursor   *Cursor `json:"-"`
+	ParentID *int64  `json:"parent_id"`
+	Mode     string  `json:"mode" validate:"oneof=flat tree"`
+	Sort     string  `json:"sort" validate:"omitempty,oneof=top new old"`
+	Depth    int     `json:"depth" validate:"gte=0"`
+	Replies  int     `json:"replies" validate:"gte=1,lte=20"`
+}
+
+func (cq *CommentsQuery) Parse(r *http.Request) error {
+	rq := r.URL.Query()
+
+	limit := rq.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return err
+		}
+		cq.Limit = l
+	}
+
+	cursor := rq.Get("cursor")
+	if cursor != "" {
+		c, err := DecodeCursor(cursor)
+		if err != nil {
+			return err
+		}
+		cq.Cursor = c
+	}
+
+	parentID := rq.Get("parent_id")
+	if parentID != "" {
+		id, err := strconv.ParseInt(parentID, 10, 64)
+		if err != nil {
+			return err
+		}
+		cq.ParentID = &id
+	}
+
+	mode := rq.Get("mode")
+	if mode != "" {
+		cq.Mode = mode
+	}
+
+	sort := rq.Get("sort")
+	if sort != "" {
+		cq.Sort = sort
+	}
+
+	if cq.Sort == CommentsTop && cq.Cursor != nil && cq.Cursor.Score == nil {
+		return ErrInvalidCursor
+	}
+
+	depth := rq.Get("depth")
+	if depth != "" {
+		d, err := strconv.Atoi(depth)
+		if err != nil {
+			return err
+		}
+		cq.Depth = d
+	}
+
+	replies := rq.Get("replies")
+	if replies != "" {
+		n, err := strconv.Atoi(replies)
+		if err != nil {
+			return err
+		}
+		cq.Replies = n
+	}
+
+	return nil
+}
+
+// CommentsPage is a page of the comments of a post or of the replies to a
+// comment. TotalCount counts all the comments of the post.
+type CommentsPage struct {
+	Comments   []*Comment `json:"comments"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	TotalCount int        `json:"total_count"`
 }
 
 type CommentsStore struct {
@@ -24,20 +170,47 @@ func NewCommentsStore(db *sql.DB) *CommentsStore {
 	}
 }
 
+// Create saves comment, resolving the mentions in comment.Entities and
+// dropping those of unknown usernames.
 func (c *CommentsStore) Create(ctx context.Context, comment *Comment) error {
-	query := `INSERT INTO comments (post_id, user_id, content) 
-	VALUES($1, $2, $3) 
+	return withTx(c.db, ctx, func(tx *sql.Tx) error {
+		entities, err := resolveMentions(ctx, tx, comment.Entities)
+		if err != nil {
+			return err
+		}
+		comment.Entities = entities
+
+		if err := c.create(ctx, tx, comment); err != nil {
+			return err
+		}
+
+		return c.createMentions(ctx, tx, comment.ID, comment.Entities.mentionedUserIDs())
+	})
+}
+
+func (c *CommentsStore) create(ctx context.Context, tx *sql.Tx, comment *Comment) error {
+	query := `INSERT INTO comments (post_id, user_id, content, entities, parent_comment_id, depth) 
+	VALUES($1, $2, $3, $4, $5, $6) 
 	RETURNING id, created_at
 	`
+
+	entities, err := entitiesJSON(comment.Entities)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	err := c.db.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		query,
 		comment.PostId,
 		comment.UserId,
 		comment.Content,
+		entities,
+		comment.ParentCommentID,
+		comment.Depth,
 	).Scan(
 		&comment.ID,
 		&comment.CreatedAt,
@@ -47,44 +220,542 @@ func (c *CommentsStore) Create(ctx context.Context, comment *Comment) error {
 		return err
 	}
 
+	comment.ReactionCounts = ReactionCounts{}
+
 	return nil
 }
 
-func (c *CommentsStore) GetByPostId(ctx context.Context, postId int64) ([]Comment, error) {
+func (c *CommentsStore) createMentions(ctx context.Context, tx *sql.Tx, commentId int64, userIds []int64) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+
 	query := `
-	SELECT c.id, c.post_id, c.user_id, c.content, c.created_at, users.username, users.id 
+	INSERT INTO comment_mentions (comment_id, user_id)
+	SELECT $1, unnest($2::bigint[])
+	ON CONFLICT (comment_id, user_id) DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, commentId, pq.Array(userIds))
+
+	return err
+}
+
+// commentColumns returns the select list scanned by scanComment, as seen by
+// viewer. Queries alias comments as c and users as u. With no viewer, hidden
+// comments are returned in full and without a reaction.
+func commentColumns(viewer string) string {
+	myReaction := `''`
+	if viewer != "" {
+		myReaction = fmt.Sprintf(
+			`COALESCE((SELECT cr.kind FROM comment_reactions cr WHERE cr.comment_id = c.id AND cr.user_id = %s), '')`,
+			viewer,
+		)
+	}
+
+	return fmt.Sprintf(`
+		c.id, c.post_id, c.user_id, c.content, c.created_at, c.entities, c.edited_at, u.username, u.id,
+		c.parent_comment_id, c.depth, NOT %[1]s,
+		(SELECT COUNT(*) FROM comments rc WHERE rc.parent_comment_id = c.id AND %[2]s),
+		c.reaction_counts,
+		%[3]s,
+		%[4]s,
+		c.hidden_at IS NOT NULL
+	`, commentShown("c", viewer), commentShown("rc", viewer), myReaction, commentScore)
+}
+
+// commentShown returns a predicate that holds when the comment aliased as
+// comment is shown to the user whose id is bound to the viewer placeholder.
+// Hidden comments are only shown to their author and to the author of the
+// post. With no viewer, only deleted comments are left out.
+func commentShown(comment, viewer string) string {
+	if viewer == "" {
+		return comment + ".deleted_at IS NULL"
+	}
+
+	return fmt.Sprintf(`(%[1]s.deleted_at IS NULL AND (
+		%[1]s.hidden_at IS NULL OR
+		%[1]s.user_id = %[2]s OR
+		EXISTS (SELECT 1 FROM posts hp WHERE hp.id = %[1]s.post_id AND hp.user_id = %[2]s)
+	))`, comment, viewer)
+}
+
+// commentScore ranks comments in "top" order. Like the "hot" ranking of
+// link aggregators, every tenfold increase in reactions is worth as much as
+// being posted 12.5 hours later. Scores only change with reactions, so they
+// can be paged through with a cursor.
+const commentScore = `(LOG(c.reactions_count + 1)::double precision + EXTRACT(EPOCH FROM c.created_at)::double precision / 45000)`
+
+// hasReplies is an SQL condition matching comments with replies, deleted or
+// not, which are kept in listings as tombstones once deleted or hidden.
+const hasReplies = `EXISTS (SELECT 1 FROM comments r WHERE r.parent_comment_id = c.id)`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanComment(row scanner, comment *Comment) error {
+	if err := row.Scan(
+		&comment.ID,
+		&comment.PostId,
+		&comment.UserId,
+		&comment.Content,
+		&comment.CreatedAt,
+		&comment.Entities,
+		&comment.EditedAt,
+		&comment.User.Username,
+		&comment.User.ID,
+		&comment.ParentCommentID,
+		&comment.Depth,
+		&comment.Tombstone,
+		&comment.RepliesCount,
+		&comment.ReactionCounts,
+		&comment.MyReaction,
+		&comment.score,
+		&comment.Hidden,
+	); err != nil {
+		return err
+	}
+
+	comment.Edited = comment.EditedAt != nil
+
+	if comment.Tombstone {
+		comment.UserId = 0
+		comment.User = User{}
+		comment.Content = ""
+		comment.Entities = Entities{}
+		comment.ReactionCounts = ReactionCounts{}
+		comment.MyReaction = ""
+		comment.Hidden = false
+	}
+
+	return nil
+}
+
+// GetByPostId returns a page of the comments of postId selected by cq, as
+// seen by viewerId. Deleted comments with replies are listed as tombstones.
+// The first page of top-level comments starts with the highlighted comment.
+func (c *CommentsStore) GetByPostId(ctx context.Context, postId, viewerId int64, cq CommentsQuery) (*CommentsPage, error) {
+	sort := cq.Sort
+	if sort == "" {
+		// Top-level comments are listed newest first, replies oldest first
+		// so they read as a conversation.
+		sort = CommentsNew
+		if cq.ParentID != nil {
+			sort = CommentsOld
+		}
+	}
+
+	var order string
+	switch sort {
+	case CommentsTop:
+		order = `
+			($3::double precision IS NULL OR (` + commentScore + `, c.id) < ($3, $4))
+		ORDER BY ` + commentScore + ` DESC, c.id DESC`
+	case CommentsOld:
+		order = `
+			($3::timestamptz IS NULL OR (c.created_at, c.id) > ($3, $4))
+		ORDER BY c.created_at, c.id`
+	default:
+		order = `
+			($3::timestamptz IS NULL OR (c.created_at, c.id) < ($3, $4))
+		ORDER BY c.created_at DESC, c.id DESC`
+	}
+
+	query := `
+	SELECT ` + commentColumns("$6") + `
 	FROM comments c
-	JOIN users ON users.id = c.user_id
-	WHERE c.post_id = $1
-	ORDER BY c.created_at DESC
+	JOIN users u ON u.id = c.user_id
+	WHERE
+		c.post_id = $1 AND
+		c.parent_comment_id IS NOT DISTINCT FROM $2 AND
+		c.id IS DISTINCT FROM (SELECT highlighted_comment_id FROM posts WHERE id = $1) AND
+		(` + commentShown("c", "$6") + ` OR ` + hasReplies + `) AND` + order + `
+	LIMIT $5
 	`
 
+	// The cursor key is the score in "top" order, and the creation time
+	// otherwise.
+	var cursorKey any
+	var cursorID int64
+	if cq.Cursor != nil {
+		cursorKey = cq.Cursor.CreatedAt
+		if sort == CommentsTop {
+			if cq.Cursor.Score == nil {
+				return nil, ErrInvalidCursor
+			}
+			cursorKey = *cq.Cursor.Score
+		}
+		cursorID = cq.Cursor.ID
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	rows, err := c.db.QueryContext(ctx, query, postId)
+	rows, err := c.db.QueryContext(
+		ctx,
+		query,
+		postId,
+		cq.ParentID,
+		cursorKey,
+		cursorID,
+		cq.Limit+1,
+		viewerId,
+	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	comments := []Comment{}
+	page := &CommentsPage{Comments: []*Comment{}}
 
 	for rows.Next() {
-		c := Comment{}
-
-		if err := rows.Scan(
-			&c.ID,
-			&c.PostId,
-			&c.UserId,
-			&c.Content,
-			&c.CreatedAt,
-			&c.User.Username,
-			&c.User.ID,
-		); err != nil {
+		comment := &Comment{}
+		if err := scanComment(rows, comment); err != nil {
+			return nil, err
+		}
+		page.Comments = append(page.Comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Comments) > cq.Limit {
+		page.Comments = page.Comments[:cq.Limit]
+
+		last := page.Comments[cq.Limit-1]
+		next := Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		if sort == CommentsTop {
+			next.Score = &last.score
+		}
+		page.NextCursor = next.Encode()
+	}
+
+	if cq.ParentID == nil && cq.Cursor == nil {
+		highlighted, err := c.getHighlighted(ctx, postId, viewerId)
+		if err != nil {
+			return nil, err
+		}
+
+		if highlighted != nil {
+			page.Comments = append([]*Comment{highlighted}, page.Comments...)
+		}
+	}
+
+	if err := c.getReplies(ctx, page.Comments, viewerId, cq); err != nil {
+		return nil, err
+	}
+
+	if cq.Mode == CommentsFlat {
+		page.Comments = flattenComments(page.Comments, nil)
+	}
+
+	query = `SELECT COUNT(*) FROM comments c WHERE c.post_id = $1 AND ` + commentShown("c", "$2")
+
+	if err := c.db.QueryRowContext(ctx, query, postId, viewerId).Scan(&page.TotalCount); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// getHighlighted returns the highlighted comment of postId as seen by
+// viewerId, or nil if there is none.
+func (c *CommentsStore) getHighlighted(ctx context.Context, postId, viewerId int64) (*Comment, error) {
+	query := `
+	SELECT ` + commentColumns("$2") + `
+	FROM posts p
+	JOIN comments c ON c.id = p.highlighted_comment_id
+	JOIN users u ON u.id = c.user_id
+	WHERE p.id = $1 AND c.deleted_at IS NULL
+	`
+
+	comment := &Comment{Highlighted: true}
+
+	err := scanComment(c.db.QueryRowContext(ctx, query, postId, viewerId), comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return comment, nil
+}
+
+// getReplies loads cq.Depth levels of replies below comments, at most
+// cq.Replies per comment, into their Replies.
+func (c *CommentsStore) getReplies(ctx context.Context, comments []*Comment, viewerId int64, cq CommentsQuery) error {
+	query := `
+	SELECT ` + commentColumns("$3") + ` FROM (
+		SELECT
+			c.*,
+			ROW_NUMBER() OVER (PARTITION BY c.parent_comment_id ORDER BY c.created_at, c.id) AS n
+		FROM comments c
+		WHERE
+			c.parent_comment_id = ANY($1) AND
+			(` + commentShown("c", "$3") + ` OR ` + hasReplies + `)
+	) c
+	JOIN users u ON u.id = c.user_id
+	WHERE c.n <= $2
+	ORDER BY c.parent_comment_id, c.n
+	`
+
+	nodes := make(map[int64]*Comment, len(comments))
+	parents := make([]int64, 0, len(comments))
+
+	for _, comment := range comments {
+		nodes[comment.ID] = comment
+		if comment.RepliesCount > 0 || comment.Tombstone {
+			parents = append(parents, comment.ID)
+		}
+	}
+
+	for depth := 0; depth < cq.Depth && len(parents) > 0 && len(nodes) < maxCommentNodes; depth++ {
+		rows, err := c.db.QueryContext(ctx, query, pq.Array(parents), cq.Replies+1, viewerId)
+		if err != nil {
+			return err
+		}
+
+		parents = nil
+		for rows.Next() {
+			reply := &Comment{}
+			if err := scanComment(rows, reply); err != nil {
+				rows.Close()
+				return err
+			}
+
+			parent := nodes[*reply.ParentCommentID]
+
+			if len(parent.Replies) == cq.Replies {
+				last := parent.Replies[cq.Replies-1]
+				parent.RepliesNextCursor = Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+				continue
+			}
+
+			parent.Replies = append(parent.Replies, reply)
+			nodes[reply.ID] = reply
+
+			// Tombstones are only listed when they have replies, which
+			// may all be tombstones too.
+			if reply.RepliesCount > 0 || reply.Tombstone {
+				parents = append(parents, reply.ID)
+			}
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flattenComments appends comments and their replies to flat in thread
+// order, leaving the replies out of their parents.
+func flattenComments(comments []*Comment, flat []*Comment) []*Comment {
+	if flat == nil {
+		flat = []*Comment{}
+	}
+
+	for _, comment := range comments {
+		replies := comment.Replies
+		comment.Replies = nil
+
+		flat = append(flat, comment)
+		flat = flattenComments(replies, flat)
+	}
+
+	return flat
+}
+
+func (c *CommentsStore) GetById(ctx context.Context, id int64) (*Comment, error) {
+	query := `
+	SELECT ` + commentColumns("") + `
+	FROM comments c
+	JOIN users u ON u.id = c.user_id
+	WHERE c.id = $1 AND c.deleted_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	comment := Comment{}
+
+	err := scanComment(c.db.QueryRowContext(ctx, query, id), &comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
 			return nil, err
 		}
-		comments = append(comments, c)
 	}
 
-	return comments, nil
+	return &comment, nil
+}
+
+// Update saves the content of comment and marks it edited. Mentions are
+// resolved again, and users no longer mentioned lose their mention.
+func (c *CommentsStore) Update(ctx context.Context, comment *Comment) error {
+	return withTx(c.db, ctx, func(tx *sql.Tx) error {
+		entities, err := resolveMentions(ctx, tx, comment.Entities)
+		if err != nil {
+			return err
+		}
+		comment.Entities = entities
+
+		data, err := entitiesJSON(comment.Entities)
+		if err != nil {
+			return err
+		}
+
+		query := `
+		UPDATE comments
+		SET content = $2, entities = $3, edited_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING edited_at
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		err = tx.QueryRowContext(ctx, query, comment.ID, comment.Content, data).Scan(&comment.EditedAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+		comment.Edited = true
+
+		userIds := comment.Entities.mentionedUserIDs()
+
+		query = `DELETE FROM comment_mentions WHERE comment_id = $1 AND NOT user_id = ANY($2)`
+		if _, err := tx.ExecContext(ctx, query, comment.ID, pq.Array(userIds)); err != nil {
+			return err
+		}
+
+		return c.createMentions(ctx, tx, comment.ID, userIds)
+	})
+}
+
+// Highlight makes commentId, a top-level comment of postId, the highlighted
+// comment of the post, replacing any other.
+func (c *CommentsStore) Highlight(ctx context.Context, postId, commentId int64) error {
+	query := `
+	UPDATE posts SET highlighted_comment_id = $2
+	WHERE id = $1 AND EXISTS (
+		SELECT 1 FROM comments
+		WHERE id = $2 AND post_id = $1 AND parent_comment_id IS NULL AND deleted_at IS NULL AND hidden_at IS NULL
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := c.db.ExecContext(ctx, query, postId, commentId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Unhighlight removes the highlight of postId if it is on commentId.
+func (c *CommentsStore) Unhighlight(ctx context.Context, postId, commentId int64) error {
+	query := `UPDATE posts SET highlighted_comment_id = NULL WHERE id = $1 AND highlighted_comment_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := c.db.ExecContext(ctx, query, postId, commentId)
+
+	return err
+}
+
+// SetHidden hides comment id from everyone but its author and the author of
+// its post, or shows it again. Hiding the highlighted comment of the post
+// removes the highlight.
+func (c *CommentsStore) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	return withTx(c.db, ctx, func(tx *sql.Tx) error {
+		query := `
+		UPDATE comments
+		SET hidden_at = CASE WHEN $2 THEN COALESCE(hidden_at, NOW()) END
+		WHERE id = $1 AND deleted_at IS NULL
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, query, id, hidden)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		if !hidden {
+			return nil
+		}
+
+		query = `UPDATE posts SET highlighted_comment_id = NULL WHERE highlighted_comment_id = $1`
+
+		_, err = tx.ExecContext(ctx, query, id)
+
+		return err
+	})
+}
+
+// Delete moves a comment to the trash on behalf of deletedBy.
+func (c *CommentsStore) Delete(ctx context.Context, id, deletedBy int64) error {
+	query := `
+	UPDATE comments
+	SET deleted_at = NOW(), deleted_by = $2
+	WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := c.db.ExecContext(ctx, query, id, deletedBy)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
 }
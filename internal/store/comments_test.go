@@ -0,0 +1,276 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// commentIDs returns the ids of comments, in order.
+func commentIDs(comments []*Comment) []int64 {
+	var ids []int64
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	return ids
+}
+
+func TestCommentsCRUD(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	reader := createTestUser(t, db, "reader", "")
+
+	post := createTestPost(t, s, &Post{UserID: author.ID})
+
+	var comments []*Comment
+	for range 3 {
+		comments = append(comments, createTestComment(t, s, &Comment{PostId: post.ID, UserId: reader.ID}))
+	}
+
+	list := func(t *testing.T, cursor *Cursor) *CommentsPage {
+		t.Helper()
+
+		page, err := s.Comments.GetByPostId(ctx, post.ID, reader.ID, CommentsQuery{Limit: 2, Cursor: cursor, Mode: CommentsFlat, Replies: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return page
+	}
+
+	t.Run("should page through comments newest first", func(t *testing.T) {
+		page := list(t, nil)
+
+		if got, expected := commentIDs(page.Comments), []int64{comments[2].ID, comments[1].ID}; !slices.Equal(got, expected) {
+			t.Fatalf("expected %v; got %v", expected, got)
+		}
+		if page.TotalCount != 3 {
+			t.Errorf("expected a total of 3; got %d", page.TotalCount)
+		}
+
+		cursor, err := DecodeCursor(page.NextCursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		page = list(t, cursor)
+
+		if got, expected := commentIDs(page.Comments), []int64{comments[0].ID}; !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+		if page.NextCursor != "" {
+			t.Errorf("expected no more comments; got cursor %s", page.NextCursor)
+		}
+	})
+
+	t.Run("should mark edited comments", func(t *testing.T) {
+		comment := comments[0]
+
+		got, err := s.Comments.GetById(ctx, comment.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Edited || got.EditedAt != nil {
+			t.Errorf("expected a new comment not to be edited; got %+v", got)
+		}
+
+		comment.Content = "edited"
+		if err := s.Comments.Update(ctx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err = s.Comments.GetById(ctx, comment.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Content != "edited" || !got.Edited || got.EditedAt == nil {
+			t.Errorf("expected the edited comment; got %+v", got)
+		}
+	})
+
+	t.Run("should delete comments", func(t *testing.T) {
+		comment := comments[1]
+
+		if err := s.Comments.Delete(ctx, comment.ID, author.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.Comments.GetById(ctx, comment.ID); err != ErrNotFound {
+			t.Errorf("expected ErrNotFound; got %v", err)
+		}
+
+		page := list(t, nil)
+		if got, expected := commentIDs(page.Comments), []int64{comments[2].ID, comments[0].ID}; !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+		if page.TotalCount != 2 {
+			t.Errorf("expected a total of 2; got %d", page.TotalCount)
+		}
+
+		var deletedBy int64
+		if err := db.QueryRow(`SELECT deleted_by FROM comments WHERE id = $1`, comment.ID).Scan(&deletedBy); err != nil {
+			t.Fatal(err)
+		}
+		if deletedBy != author.ID {
+			t.Errorf("expected it to be deleted by %d; got %d", author.ID, deletedBy)
+		}
+	})
+
+	t.Run("should not find deleted comments", func(t *testing.T) {
+		comment := comments[1]
+
+		if err := s.Comments.Delete(ctx, comment.ID, author.ID); err != ErrNotFound {
+			t.Errorf("delete: expected ErrNotFound; got %v", err)
+		}
+
+		if err := s.Comments.Update(ctx, comment); err != ErrNotFound {
+			t.Errorf("update: expected ErrNotFound; got %v", err)
+		}
+	})
+}
+
+func TestCommentReplies(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	reader := createTestUser(t, db, "reader", "")
+
+	post := createTestPost(t, s, &Post{UserID: author.ID})
+
+	reply := func(parent *Comment) *Comment {
+		comment := &Comment{PostId: post.ID, UserId: reader.ID}
+		if parent != nil {
+			comment.ParentCommentID = &parent.ID
+			comment.Depth = parent.Depth + 1
+		}
+		return createTestComment(t, s, comment)
+	}
+
+	root := reply(nil)
+	a := reply(root)
+	b := reply(root)
+	c := reply(root)
+	a1 := reply(a)
+
+	list := func(t *testing.T, cq CommentsQuery) *CommentsPage {
+		t.Helper()
+
+		if cq.Limit == 0 {
+			cq.Limit = 10
+		}
+
+		page, err := s.Comments.GetByPostId(ctx, post.ID, reader.ID, cq)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return page
+	}
+
+	t.Run("should nest replies in tree mode", func(t *testing.T) {
+		page := list(t, CommentsQuery{Mode: CommentsTree, Depth: 2, Replies: 10})
+
+		if len(page.Comments) != 1 || page.Comments[0].ID != root.ID {
+			t.Fatalf("expected only %d at the top; got %v", root.ID, commentIDs(page.Comments))
+		}
+
+		top := page.Comments[0]
+		if got, expected := commentIDs(top.Replies), []int64{a.ID, b.ID, c.ID}; !slices.Equal(got, expected) {
+			t.Fatalf("expected the replies oldest first %v; got %v", expected, got)
+		}
+		if top.RepliesCount != 3 {
+			t.Errorf("expected 3 replies; got %d", top.RepliesCount)
+		}
+
+		if got := commentIDs(top.Replies[0].Replies); !slices.Equal(got, []int64{a1.ID}) {
+			t.Errorf("expected %d to reply to %d; got %v", a1.ID, a.ID, got)
+		}
+		if page.TotalCount != 5 {
+			t.Errorf("expected a total of 5; got %d", page.TotalCount)
+		}
+	})
+
+	t.Run("should flatten replies in thread order", func(t *testing.T) {
+		page := list(t, CommentsQuery{Mode: CommentsFlat, Depth: 2, Replies: 10})
+
+		expected := []int64{root.ID, a.ID, a1.ID, b.ID, c.ID}
+		if got := commentIDs(page.Comments); !slices.Equal(got, expected) {
+			t.Fatalf("expected %v; got %v", expected, got)
+		}
+
+		var depths []int
+		for _, comment := range page.Comments {
+			depths = append(depths, comment.Depth)
+			if comment.Replies != nil {
+				t.Errorf("expected no nested replies in flat mode; got %v under %d", commentIDs(comment.Replies), comment.ID)
+			}
+		}
+		if !slices.Equal(depths, []int{0, 1, 2, 1, 1}) {
+			t.Errorf("expected depths [0 1 2 1 1]; got %v", depths)
+		}
+	})
+
+	t.Run("should stop at the depth", func(t *testing.T) {
+		page := list(t, CommentsQuery{Mode: CommentsTree, Depth: 1, Replies: 10})
+
+		nested := page.Comments[0].Replies[0]
+		if len(nested.Replies) != 0 {
+			t.Errorf("expected no replies below depth 1; got %v", commentIDs(nested.Replies))
+		}
+		if nested.RepliesCount != 1 {
+			t.Errorf("expected the count of the replies left out; got %d", nested.RepliesCount)
+		}
+	})
+
+	t.Run("should expand replies page by page", func(t *testing.T) {
+		page := list(t, CommentsQuery{Mode: CommentsTree, Depth: 1, Replies: 2})
+
+		top := page.Comments[0]
+		if got, expected := commentIDs(top.Replies), []int64{a.ID, b.ID}; !slices.Equal(got, expected) {
+			t.Fatalf("expected %v; got %v", expected, got)
+		}
+
+		cursor, err := DecodeCursor(top.RepliesNextCursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		page = list(t, CommentsQuery{ParentID: &root.ID, Cursor: cursor, Mode: CommentsTree, Replies: 2})
+
+		if got, expected := commentIDs(page.Comments), []int64{c.ID}; !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+		if page.NextCursor != "" {
+			t.Errorf("expected no more replies; got cursor %s", page.NextCursor)
+		}
+	})
+
+	t.Run("should leave tombstones of deleted comments with replies", func(t *testing.T) {
+		for _, comment := range []*Comment{a, c} {
+			if err := s.Comments.Delete(ctx, comment.ID, reader.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		page := list(t, CommentsQuery{Mode: CommentsTree, Depth: 2, Replies: 10})
+
+		top := page.Comments[0]
+		if got, expected := commentIDs(top.Replies), []int64{a.ID, b.ID}; !slices.Equal(got, expected) {
+			t.Fatalf("expected %v; got %v", expected, got)
+		}
+
+		tombstone := top.Replies[0]
+		if !tombstone.Tombstone || tombstone.Content != "" || tombstone.UserId != 0 {
+			t.Errorf("expected %d to be a tombstone; got %+v", a.ID, tombstone)
+		}
+		if got := commentIDs(tombstone.Replies); !slices.Equal(got, []int64{a1.ID}) {
+			t.Errorf("expected the replies of the tombstone to be kept; got %v", got)
+		}
+		if top.RepliesCount != 1 {
+			t.Errorf("expected 1 reply left; got %d", top.RepliesCount)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at a row in a list ordered by (created_at, id). It is handed
+// to clients as an opaque string, so its layout may change without notice.
+type Cursor struct {
+	CreatedAt string `json:"c"`
+	ID        int64  `json:"i"`
+	// Score is set instead in lists ordered by (score, id).
+	Score *float64 `json:"s,omitempty"`
+	// Kind is set in lists that mix kinds of rows, whose ids may collide.
+	Kind string `json:"k,omitempty"`
+	// Before asks for the rows before the one pointed at rather than after
+	// it, in lists that can be paged through both ways.
+	Before bool `json:"b,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if c.CreatedAt == "" || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
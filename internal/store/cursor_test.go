@@ -0,0 +1,40 @@
+package store
+
+import "testing"
+
+func TestCursor(t *testing.T) {
+	t.Run("should round-trip through its encoded form", func(t *testing.T) {
+		c := Cursor{CreatedAt: "2025-01-11T10:00:00Z", ID: 42}
+
+		decoded, err := DecodeCursor(c.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if *decoded != c {
+			t.Errorf("expected %+v; got %+v", c, *decoded)
+		}
+	})
+
+	t.Run("should keep scores exactly", func(t *testing.T) {
+		score := 38912.123456789012
+		c := Cursor{CreatedAt: "2025-01-11T10:00:00Z", ID: 42, Score: &score}
+
+		decoded, err := DecodeCursor(c.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.Score == nil || *decoded.Score != score {
+			t.Errorf("expected score %v; got %v", score, decoded.Score)
+		}
+	})
+
+	t.Run("should reject malformed cursors", func(t *testing.T) {
+		for _, s := range []string{"not base64!", "e30", Cursor{ID: 1}.Encode()} {
+			if _, err := DecodeCursor(s); err != ErrInvalidCursor {
+				t.Errorf("expected ErrInvalidCursor for %q; got %v", s, err)
+			}
+		}
+	})
+}
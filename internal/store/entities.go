@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+const (
+	EntityHashtag = "hashtag"
+	EntityMention = "mention"
+)
+
+// Entity is a hashtag or a mention in the content of a post or comment, for
+// clients to render as a link. Start and End are offsets in characters
+// (Unicode code points) into the content, End being exclusive.
+type Entity struct {
+	Type  string `json:"type"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	// Text is the tag in its canonical form, or the mentioned username.
+	Text   string `json:"text"`
+	UserID int64  `json:"user_id,omitempty"`
+}
+
+// Entities is stored denormalized on posts.entities and comments.entities.
+type Entities []Entity
+
+func (e *Entities) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*e = Entities{}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Entities", src)
+	}
+
+	entities := Entities{}
+	if err := json.Unmarshal(data, &entities); err != nil {
+		return err
+	}
+
+	*e = entities
+	return nil
+}
+
+// ParseEntities finds the #hashtags and @mentions in content. A marker only
+// starts an entity at the beginning of a word, so e-mail addresses and
+// URL fragments are left alone. Hashtags that are not valid tags are
+// skipped. Mentions are not resolved; see resolveMentions.
+func ParseEntities(content string) Entities {
+	entities := Entities{}
+	runes := []rune(content)
+
+	for i := 0; i < len(runes); i++ {
+		marker := runes[i]
+		if marker != '#' && marker != '@' {
+			continue
+		}
+
+		if i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == marker) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && (isTagRune(runes[end]) || (marker == '@' && isInnerUsernameRune(runes[end]))) {
+			end++
+		}
+
+		// Usernames may contain dots and dashes, but not at the end, where
+		// they are more likely to be punctuation.
+		for end > i+1 && isInnerUsernameRune(runes[end-1]) {
+			end--
+		}
+
+		if end == i+1 {
+			continue
+		}
+
+		entity := Entity{Start: i, End: end}
+
+		switch marker {
+		case '#':
+			tag, err := NormalizeTag(string(runes[i+1 : end]))
+			if err != nil {
+				i = end - 1
+				continue
+			}
+			entity.Type = EntityHashtag
+			entity.Text = tag
+		case '@':
+			entity.Type = EntityMention
+			entity.Text = string(runes[i+1 : end])
+		}
+
+		entities = append(entities, entity)
+		i = end - 1
+	}
+
+	return entities
+}
+
+// Hashtags returns the tags of the hashtag entities.
+func (e Entities) Hashtags() []string {
+	var tags []string
+
+	for _, entity := range e {
+		if entity.Type == EntityHashtag {
+			tags = append(tags, entity.Text)
+		}
+	}
+
+	return tags
+}
+
+// mentionedUserIDs returns the distinct ids of the resolved mentions.
+func (e Entities) mentionedUserIDs() []int64 {
+	ids := []int64{}
+	seen := map[int64]bool{}
+
+	for _, entity := range e {
+		if entity.Type == EntityMention && entity.UserID != 0 && !seen[entity.UserID] {
+			seen[entity.UserID] = true
+			ids = append(ids, entity.UserID)
+		}
+	}
+
+	return ids
+}
+
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isInnerUsernameRune(r rune) bool {
+	return r == '.' || r == '-'
+}
+
+// resolveMentions sets the user id of every mention in entities, and drops
+// mentions of usernames that don't exist.
+func resolveMentions(ctx context.Context, tx *sql.Tx, entities Entities) (Entities, error) {
+	var usernames []string
+
+	for _, entity := range entities {
+		if entity.Type == EntityMention {
+			usernames = append(usernames, entity.Text)
+		}
+	}
+
+	if len(usernames) == 0 {
+		return entities, nil
+	}
+
+	query := `SELECT id, username FROM users WHERE username = ANY($1)`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(usernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[string]int64{}
+	for rows.Next() {
+		var id int64
+		var username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, err
+		}
+		ids[username] = id
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	resolved := make(Entities, 0, len(entities))
+
+	for _, entity := range entities {
+		if entity.Type == EntityMention {
+			id, ok := ids[entity.Text]
+			if !ok {
+				continue
+			}
+			entity.UserID = id
+		}
+		resolved = append(resolved, entity)
+	}
+
+	return resolved, nil
+}
+
+// entitiesJSON encodes entities for the entities columns.
+func entitiesJSON(entities Entities) ([]byte, error) {
+	if entities == nil {
+		entities = Entities{}
+	}
+
+	return json.Marshal(entities)
+}
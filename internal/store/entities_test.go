@@ -0,0 +1,38 @@
+package store
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseEntities(t *testing.T) {
+	t.Run("should find hashtags and mentions with their offsets", func(t *testing.T) {
+		entities := ParseEntities("Hi @jane.doe, try #GoLang! Ünï #café.")
+
+		expected := Entities{
+			{Type: EntityMention, Start: 3, End: 12, Text: "jane.doe"},
+			{Type: EntityHashtag, Start: 18, End: 25, Text: "golang"},
+			{Type: EntityHashtag, Start: 31, End: 36, Text: "café"},
+		}
+
+		if !slices.Equal(entities, expected) {
+			t.Errorf("expected %+v; got %+v", expected, entities)
+		}
+	})
+
+	t.Run("should skip markers inside words", func(t *testing.T) {
+		for _, content := range []string{"mail me at jane@example.com", "page.html#anchor", "##double", "@@double", "# alone", "@"} {
+			if entities := ParseEntities(content); len(entities) != 0 {
+				t.Errorf("expected no entities in %q; got %+v", content, entities)
+			}
+		}
+	})
+
+	t.Run("should skip hashtags that are not valid tags", func(t *testing.T) {
+		entities := ParseEntities("#aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa #ok")
+
+		if len(entities) != 1 || entities[0].Text != "ok" {
+			t.Errorf("expected only #ok; got %+v", entities)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LinkPreview describes the page at the first URL in the content of a post.
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// linkPreviewJSON returns an SQL expression that builds the LinkPreview of
+// the post aliased as post, or NULL while it hasn't been fetched.
+func linkPreviewJSON(post string) string {
+	return fmt.Sprintf(`(
+		SELECT json_build_object(
+			'url', lp.url, 'title', lp.title, 'description', lp.description,
+			'image', lp.image_url, 'site_name', lp.site_name
+		)
+		FROM link_previews lp
+		WHERE lp.url = %s.link_url AND NOT lp.failed
+	)`, post)
+}
+
+type LinkPreviewsStore struct {
+	db *sql.DB
+}
+
+func NewLinkPreviewsStore(db *sql.DB) *LinkPreviewsStore {
+	return &LinkPreviewsStore{db: db}
+}
+
+// GetPending returns up to limit URLs linked from posts that have no
+// preview yet, or one fetched before staleBefore.
+func (s *LinkPreviewsStore) GetPending(ctx context.Context, staleBefore time.Time, limit int) ([]string, error) {
+	query := `
+		SELECT DISTINCT p.link_url
+		FROM posts p
+		LEFT JOIN link_previews lp ON lp.url = p.link_url
+		WHERE
+			p.link_url IS NOT NULL AND
+			p.deleted_at IS NULL AND
+			(lp.url IS NULL OR lp.fetched_at < $1)
+		LIMIT $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, staleBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+
+	return urls, rows.Err()
+}
+
+// Save stores the preview of preview.URL.
+func (s *LinkPreviewsStore) Save(ctx context.Context, preview *LinkPreview) error {
+	return s.save(ctx, preview, false)
+}
+
+// SaveFailed records that url has no preview.
+func (s *LinkPreviewsStore) SaveFailed(ctx context.Context, url string) error {
+	return s.save(ctx, &LinkPreview{URL: url}, true)
+}
+
+func (s *LinkPreviewsStore) save(ctx context.Context, preview *LinkPreview, failed bool) error {
+	query := `
+		INSERT INTO link_previews (url, title, description, image_url, site_name, failed)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (url) DO UPDATE SET
+			title = EXCLUDED.title, description = EXCLUDED.description,
+			image_url = EXCLUDED.image_url, site_name = EXCLUDED.site_name,
+			failed = EXCLUDED.failed, fetched_at = NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		preview.URL,
+		preview.Title,
+		preview.Description,
+		preview.Image,
+		preview.SiteName,
+		failed,
+	)
+
+	return err
+}
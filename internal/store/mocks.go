@@ -4,11 +4,32 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/stretchr/testify/mock"
 )
 
 func NewMockStore() Storage {
 	return Storage{
-		Users: &MockUsersStore{},
+		Users:            &MockUsersStore{},
+		Posts:            &MockPostsStore{},
+		Comments:         &MockCommentsStore{},
+		CommentReactions: &MockCommentReactionsStore{},
+		Followers:        &MockFollowerStore{},
+		Blocks:           &MockBlocksStore{},
+		Roles:            &MockRolesStore{},
+		Reactions:        &MockReactionsStore{},
+		Bookmarks:        &MockBookmarksStore{},
+		Reposts:          &MockRepostsStore{},
+		Revisions:        &MockRevisionsStore{},
+		Trash:            &MockTrashStore{},
+		Tags:             &MockTagsStore{},
+		Polls:            &MockPollsStore{},
+		Trending:         &MockTrendingStore{},
+		Pins:             &MockPinsStore{},
+		Preferences:      &MockPreferencesStore{},
+		Moderation:       &MockModerationStore{},
+		Analytics:        &MockAnalyticsStore{},
+		LinkPreviews:     &MockLinkPreviewsStore{},
 	}
 }
 
@@ -37,3 +58,466 @@ func (m *MockUsersStore) Activate(ctx context.Context, token string) error {
 func (m *MockUsersStore) Delete(ctx context.Context, id int64) error {
 	return nil
 }
+
+// MockRolesStore has the roles created by the migrations.
+type MockRolesStore struct{}
+
+var mockRoles = []Role{
+	{Id: 1, Name: "user", Level: 1},
+	{Id: 2, Name: "moderator", Level: 2},
+	{Id: 3, Name: "admin", Level: 3},
+}
+
+func (m *MockRolesStore) GetByName(ctx context.Context, name string) (*Role, error) {
+	for _, role := range mockRoles {
+		if role.Name == name {
+			return &role, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+type MockPostsStore struct {
+	mock.Mock
+}
+
+func (m *MockPostsStore) Create(ctx context.Context, post *Post) error {
+	args := m.Called(post)
+	return args.Error(0)
+}
+
+func (m *MockPostsStore) GetById(ctx context.Context, id int64) (*Post, error) {
+	args := m.Called(id)
+	post, _ := args.Get(0).(*Post)
+	return post, args.Error(1)
+}
+
+func (m *MockPostsStore) DeleteById(ctx context.Context, id int64, version int, deletedBy int64) error {
+	args := m.Called(id, version, deletedBy)
+	return args.Error(0)
+}
+
+func (m *MockPostsStore) UpdateById(ctx context.Context, post *Post, editorId int64) error {
+	args := m.Called(post, editorId)
+	return args.Error(0)
+}
+
+func (m *MockPostsStore) GetUserFeed(ctx context.Context, userId int64, pfq PaginatedFeedQuery) (*FeedPage, error) {
+	args := m.Called(userId, pfq)
+	page, _ := args.Get(0).(*FeedPage)
+	return page, args.Error(1)
+}
+
+func (m *MockPostsStore) GetByUser(ctx context.Context, userId, viewerId int64, ppq PaginatedPostsQuery) (*PostsPage, error) {
+	args := m.Called(userId, viewerId, ppq)
+	page, _ := args.Get(0).(*PostsPage)
+	return page, args.Error(1)
+}
+
+func (m *MockPostsStore) IsVisibleTo(ctx context.Context, postId, viewerId int64) (bool, error) {
+	args := m.Called(postId, viewerId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPostsStore) CanComment(ctx context.Context, postId, userId int64) (bool, error) {
+	args := m.Called(postId, userId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPostsStore) GetDrafts(ctx context.Context, userId int64, ppq PaginatedPostsQuery) (*PostsPage, error) {
+	args := m.Called(userId, ppq)
+	page, _ := args.Get(0).(*PostsPage)
+	return page, args.Error(1)
+}
+
+func (m *MockPostsStore) GetMentioning(ctx context.Context, userId int64, ppq PaginatedPostsQuery) (*PostsPage, error) {
+	args := m.Called(userId, ppq)
+	page, _ := args.Get(0).(*PostsPage)
+	return page, args.Error(1)
+}
+
+func (m *MockPostsStore) GetByIds(ctx context.Context, viewerId int64, ids []int64) ([]*PostForFeed, error) {
+	args := m.Called(viewerId, ids)
+	posts, _ := args.Get(0).([]*PostForFeed)
+	return posts, args.Error(1)
+}
+
+func (m *MockPostsStore) GetByTag(ctx context.Context, tag string, viewerId int64, ppq PaginatedPostsQuery) (*PostsPage, error) {
+	args := m.Called(tag, viewerId, ppq)
+	page, _ := args.Get(0).(*PostsPage)
+	return page, args.Error(1)
+}
+
+func (m *MockPostsStore) GetThread(ctx context.Context, postId, viewerId int64, tq ThreadQuery) (*ThreadNode, error) {
+	args := m.Called(postId, viewerId, tq)
+	thread, _ := args.Get(0).(*ThreadNode)
+	return thread, args.Error(1)
+}
+
+func (m *MockPostsStore) PublishDue(ctx context.Context, limit int) ([]int64, error) {
+	args := m.Called(limit)
+	ids, _ := args.Get(0).([]int64)
+	return ids, args.Error(1)
+}
+
+type MockCommentsStore struct {
+	mock.Mock
+}
+
+func (m *MockCommentsStore) GetByPostId(ctx context.Context, postId, viewerId int64, cq CommentsQuery) (*CommentsPage, error) {
+	args := m.Called(postId, viewerId, cq)
+	page, _ := args.Get(0).(*CommentsPage)
+	return page, args.Error(1)
+}
+
+func (m *MockCommentsStore) GetById(ctx context.Context, id int64) (*Comment, error) {
+	args := m.Called(id)
+	comment, _ := args.Get(0).(*Comment)
+	return comment, args.Error(1)
+}
+
+func (m *MockCommentsStore) Create(ctx context.Context, comment *Comment) error {
+	args := m.Called(comment)
+	return args.Error(0)
+}
+
+func (m *MockCommentsStore) Update(ctx context.Context, comment *Comment) error {
+	args := m.Called(comment)
+	return args.Error(0)
+}
+
+func (m *MockCommentsStore) Delete(ctx context.Context, id, deletedBy int64) error {
+	args := m.Called(id, deletedBy)
+	return args.Error(0)
+}
+
+func (m *MockCommentsStore) Highlight(ctx context.Context, postId, commentId int64) error {
+	args := m.Called(postId, commentId)
+	return args.Error(0)
+}
+
+func (m *MockCommentsStore) Unhighlight(ctx context.Context, postId, commentId int64) error {
+	args := m.Called(postId, commentId)
+	return args.Error(0)
+}
+
+func (m *MockCommentsStore) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	args := m.Called(id, hidden)
+	return args.Error(0)
+}
+
+type MockCommentReactionsStore struct {
+	mock.Mock
+}
+
+func (m *MockCommentReactionsStore) Set(ctx context.Context, commentId, userId int64, kind string) error {
+	args := m.Called(commentId, userId, kind)
+	return args.Error(0)
+}
+
+func (m *MockCommentReactionsStore) Delete(ctx context.Context, commentId, userId int64) error {
+	args := m.Called(commentId, userId)
+	return args.Error(0)
+}
+
+type MockFollowerStore struct {
+	mock.Mock
+}
+
+func (m *MockFollowerStore) Follow(ctx context.Context, followerId, userId int64) error {
+	args := m.Called(followerId, userId)
+	return args.Error(0)
+}
+
+func (m *MockFollowerStore) Unfollow(ctx context.Context, followerId, userId int64) error {
+	args := m.Called(followerId, userId)
+	return args.Error(0)
+}
+
+type MockBlocksStore struct {
+	mock.Mock
+}
+
+func (m *MockBlocksStore) Block(ctx context.Context, userId, blockedId int64) error {
+	args := m.Called(userId, blockedId)
+	return args.Error(0)
+}
+
+func (m *MockBlocksStore) Unblock(ctx context.Context, userId, blockedId int64) error {
+	args := m.Called(userId, blockedId)
+	return args.Error(0)
+}
+
+type MockReactionsStore struct {
+	mock.Mock
+}
+
+func (m *MockReactionsStore) Set(ctx context.Context, postId, userId int64, kind string) error {
+	args := m.Called(postId, userId, kind)
+	return args.Error(0)
+}
+
+func (m *MockReactionsStore) Delete(ctx context.Context, postId, userId int64) error {
+	args := m.Called(postId, userId)
+	return args.Error(0)
+}
+
+func (m *MockReactionsStore) GetByUser(ctx context.Context, postId, userId int64) (string, error) {
+	args := m.Called(postId, userId)
+	return args.String(0), args.Error(1)
+}
+
+type MockBookmarksStore struct {
+	mock.Mock
+}
+
+func (m *MockBookmarksStore) GetCollections(ctx context.Context, userId int64) ([]BookmarkCollection, error) {
+	args := m.Called(userId)
+	collections, _ := args.Get(0).([]BookmarkCollection)
+	return collections, args.Error(1)
+}
+
+func (m *MockBookmarksStore) CreateCollection(ctx context.Context, collection *BookmarkCollection) error {
+	args := m.Called(collection)
+	return args.Error(0)
+}
+
+func (m *MockBookmarksStore) DeleteCollection(ctx context.Context, collectionId, userId int64) error {
+	args := m.Called(collectionId, userId)
+	return args.Error(0)
+}
+
+func (m *MockBookmarksStore) Add(ctx context.Context, userId, collectionId, postId int64) error {
+	args := m.Called(userId, collectionId, postId)
+	return args.Error(0)
+}
+
+func (m *MockBookmarksStore) Remove(ctx context.Context, userId, collectionId, postId int64) error {
+	args := m.Called(userId, collectionId, postId)
+	return args.Error(0)
+}
+
+func (m *MockBookmarksStore) GetPosts(ctx context.Context, userId, collectionId int64, ppq PaginatedPostsQuery) (*PostsPage, error) {
+	args := m.Called(userId, collectionId, ppq)
+	page, _ := args.Get(0).(*PostsPage)
+	return page, args.Error(1)
+}
+
+type MockRepostsStore struct {
+	mock.Mock
+}
+
+func (m *MockRepostsStore) Create(ctx context.Context, userId, postId int64) error {
+	args := m.Called(userId, postId)
+	return args.Error(0)
+}
+
+func (m *MockRepostsStore) Delete(ctx context.Context, userId, postId int64) error {
+	args := m.Called(userId, postId)
+	return args.Error(0)
+}
+
+type MockRevisionsStore struct {
+	mock.Mock
+}
+
+func (m *MockRevisionsStore) GetByPostId(ctx context.Context, postId int64) ([]Revision, error) {
+	args := m.Called(postId)
+	revisions, _ := args.Get(0).([]Revision)
+	return revisions, args.Error(1)
+}
+
+func (m *MockRevisionsStore) GetByVersion(ctx context.Context, postId int64, version int) (*Revision, error) {
+	args := m.Called(postId, version)
+	revision, _ := args.Get(0).(*Revision)
+	return revision, args.Error(1)
+}
+
+type MockTrashStore struct {
+	mock.Mock
+}
+
+func (m *MockTrashStore) Get(ctx context.Context, userId int64, since time.Time, tq TrashQuery) (*Trash, error) {
+	args := m.Called(userId, since, tq)
+	trash, _ := args.Get(0).(*Trash)
+	return trash, args.Error(1)
+}
+
+func (m *MockTrashStore) RestorePost(ctx context.Context, postId, userId int64, since time.Time, anyone bool) error {
+	args := m.Called(postId, userId, since, anyone)
+	return args.Error(0)
+}
+
+func (m *MockTrashStore) RestoreComment(ctx context.Context, commentId, userId int64, since time.Time, anyone bool) error {
+	args := m.Called(commentId, userId, since, anyone)
+	return args.Error(0)
+}
+
+func (m *MockTrashStore) Purge(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(before, limit)
+	n, _ := args.Get(0).(int64)
+	return n, args.Error(1)
+}
+
+type MockTagsStore struct {
+	mock.Mock
+}
+
+func (m *MockTagsStore) GetAliases(ctx context.Context) ([]TagAlias, error) {
+	args := m.Called()
+	aliases, _ := args.Get(0).([]TagAlias)
+	return aliases, args.Error(1)
+}
+
+func (m *MockTagsStore) CreateAlias(ctx context.Context, alias *TagAlias) error {
+	args := m.Called(alias)
+	return args.Error(0)
+}
+
+func (m *MockTagsStore) DeleteAlias(ctx context.Context, alias string) error {
+	args := m.Called(alias)
+	return args.Error(0)
+}
+
+func (m *MockTagsStore) Follow(ctx context.Context, userId int64, tag string) error {
+	args := m.Called(userId, tag)
+	return args.Error(0)
+}
+
+func (m *MockTagsStore) Unfollow(ctx context.Context, userId int64, tag string) error {
+	args := m.Called(userId, tag)
+	return args.Error(0)
+}
+
+func (m *MockTagsStore) GetFollowed(ctx context.Context, userId int64) ([]string, error) {
+	args := m.Called(userId)
+	tags, _ := args.Get(0).([]string)
+	return tags, args.Error(1)
+}
+
+type MockPollsStore struct {
+	mock.Mock
+}
+
+func (m *MockPollsStore) Get(ctx context.Context, postId, viewerId int64) (*Poll, error) {
+	args := m.Called(postId, viewerId)
+	poll, _ := args.Get(0).(*Poll)
+	return poll, args.Error(1)
+}
+
+func (m *MockPollsStore) Vote(ctx context.Context, postId, userId int64, optionIds []int64) error {
+	args := m.Called(postId, userId, optionIds)
+	return args.Error(0)
+}
+
+type MockTrendingStore struct {
+	mock.Mock
+}
+
+func (m *MockTrendingStore) ComputePosts(ctx context.Context, limit int) ([]TrendingPost, error) {
+	args := m.Called(limit)
+	posts, _ := args.Get(0).([]TrendingPost)
+	return posts, args.Error(1)
+}
+
+func (m *MockTrendingStore) ComputeTags(ctx context.Context, limit int) ([]TrendingTag, error) {
+	args := m.Called(limit)
+	tags, _ := args.Get(0).([]TrendingTag)
+	return tags, args.Error(1)
+}
+
+func (m *MockTrendingStore) GetPosts(ctx context.Context, limit int) ([]TrendingPost, error) {
+	args := m.Called(limit)
+	posts, _ := args.Get(0).([]TrendingPost)
+	return posts, args.Error(1)
+}
+
+func (m *MockTrendingStore) GetTags(ctx context.Context, limit int) ([]TrendingTag, error) {
+	args := m.Called(limit)
+	tags, _ := args.Get(0).([]TrendingTag)
+	return tags, args.Error(1)
+}
+
+func (m *MockTrendingStore) Refresh(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+type MockPinsStore struct {
+	mock.Mock
+}
+
+func (m *MockPinsStore) Pin(ctx context.Context, userId, postId int64) error {
+	args := m.Called(userId, postId)
+	return args.Error(0)
+}
+
+func (m *MockPinsStore) Unpin(ctx context.Context, userId, postId int64) error {
+	args := m.Called(userId, postId)
+	return args.Error(0)
+}
+
+func (m *MockPinsStore) Reorder(ctx context.Context, userId int64, postIds []int64) error {
+	args := m.Called(userId, postIds)
+	return args.Error(0)
+}
+
+type MockPreferencesStore struct {
+	mock.Mock
+}
+
+func (m *MockPreferencesStore) Get(ctx context.Context, userId int64) (*Preferences, error) {
+	args := m.Called(userId)
+	prefs, _ := args.Get(0).(*Preferences)
+	return prefs, args.Error(1)
+}
+
+func (m *MockPreferencesStore) Update(ctx context.Context, userId int64, prefs *Preferences) error {
+	args := m.Called(userId, prefs)
+	return args.Error(0)
+}
+
+type MockModerationStore struct {
+	mock.Mock
+}
+
+func (m *MockModerationStore) SetContentWarning(ctx context.Context, postId, moderatorId int64, cw ContentWarning, reason string) (int, error) {
+	args := m.Called(postId, moderatorId, cw, reason)
+	return args.Int(0), args.Error(1)
+}
+
+type MockAnalyticsStore struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsStore) AddViews(ctx context.Context, views []DailyViews) error {
+	args := m.Called(views)
+	return args.Error(0)
+}
+
+func (m *MockAnalyticsStore) Get(ctx context.Context, userId int64, from time.Time, postsLimit int) (*Analytics, error) {
+	args := m.Called(userId, from, postsLimit)
+	analytics, _ := args.Get(0).(*Analytics)
+	return analytics, args.Error(1)
+}
+
+type MockLinkPreviewsStore struct {
+	mock.Mock
+}
+
+func (m *MockLinkPreviewsStore) GetPending(ctx context.Context, staleBefore time.Time, limit int) ([]string, error) {
+	args := m.Called(staleBefore, limit)
+	tags, _ := args.Get(0).([]string)
+	return tags, args.Error(1)
+}
+
+func (m *MockLinkPreviewsStore) Save(ctx context.Context, preview *LinkPreview) error {
+	args := m.Called(preview)
+	return args.Error(0)
+}
+
+func (m *MockLinkPreviewsStore) SaveFailed(ctx context.Context, url string) error {
+	args := m.Called(url)
+	return args.Error(0)
+}
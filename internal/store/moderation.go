@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// Actions recorded in the moderation log.
+const (
+	ModerationContentWarning = "content_warning"
+)
+
+// ContentWarning is the content warning and sensitive flag of a post.
+type ContentWarning struct {
+	ContentWarning string `json:"content_warning"`
+	Sensitive      bool   `json:"sensitive"`
+}
+
+type ModerationStore struct {
+	db *sql.DB
+}
+
+func NewModerationStore(db *sql.DB) *ModerationStore {
+	return &ModerationStore{db: db}
+}
+
+// SetContentWarning applies cw to postId on behalf of moderatorId and
+// records it in the moderation log with reason. The author can't lift a
+// warning set this way; setting an empty one lifts it and gives control back
+// to the author. The new version of the post is recorded as a revision by
+// moderatorId, and returned.
+func (s *ModerationStore) SetContentWarning(ctx context.Context, postId, moderatorId int64, cw ContentWarning, reason string) (int, error) {
+	post := &Post{ID: postId}
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE posts
+			SET content_warning = $2, sensitive = $3, content_warning_forced = ($2 <> '' OR $3),
+				version = version + 1, updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING version, title, content
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		err := tx.QueryRowContext(ctx, query, postId, cw.ContentWarning, cw.Sensitive).Scan(
+			&post.Version,
+			&post.Title,
+			&post.Content,
+		)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if err := createRevision(ctx, tx, post, moderatorId); err != nil {
+			return err
+		}
+
+		return logModeration(ctx, tx, moderatorId, postId, ModerationContentWarning, cw, reason)
+	})
+
+	return post.Version, err
+}
+
+func logModeration(ctx context.Context, tx *sql.Tx, moderatorId, postId int64, action string, details any, reason string) error {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO moderation_log (moderator_id, post_id, action, details, reason)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err = tx.ExecContext(ctx, query, moderatorId, postId, action, data, reason)
+
+	return err
+}
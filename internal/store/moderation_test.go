@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestSetContentWarning(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	moderator := createTestUser(t, db, "moderator", "moderator")
+
+	post := createTestPost(t, s, &Post{UserID: author.ID})
+
+	t.Run("should force the warning and log it", func(t *testing.T) {
+		cw := ContentWarning{ContentWarning: "spoilers", Sensitive: true}
+
+		version, err := s.Moderation.SetContentWarning(ctx, post.ID, moderator.ID, cw, "unmarked spoilers")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != post.Version+1 {
+			t.Errorf("expected version %d; got %d", post.Version+1, version)
+		}
+
+		got, err := s.Posts.GetById(ctx, post.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ContentWarning != "spoilers" || !got.Sensitive || !got.ContentWarningForced {
+			t.Errorf("expected a forced warning; got %q, sensitive %t, forced %t", got.ContentWarning, got.Sensitive, got.ContentWarningForced)
+		}
+
+		var action, reason string
+		var details []byte
+
+		err = db.QueryRow(
+			`SELECT action, details, reason FROM moderation_log WHERE post_id = $1 AND moderator_id = $2`,
+			post.ID, moderator.ID,
+		).Scan(&action, &details, &reason)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var logged ContentWarning
+		if err := json.Unmarshal(details, &logged); err != nil {
+			t.Fatal(err)
+		}
+
+		if action != ModerationContentWarning || logged != cw || reason != "unmarked spoilers" {
+			t.Errorf("expected %s of %+v for the reason; got %s of %+v for %q", ModerationContentWarning, cw, action, logged, reason)
+		}
+
+		revisions, err := s.Revisions.GetByPostId(ctx, post.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if edit := revisions[0]; edit.Version != version || edit.Editor == nil || edit.Editor.ID != moderator.ID || !edit.ModeratorEdit {
+			t.Errorf("expected a moderator revision of version %d; got %+v", version, edit)
+		}
+	})
+
+	t.Run("should give control back once lifted", func(t *testing.T) {
+		if _, err := s.Moderation.SetContentWarning(ctx, post.ID, moderator.ID, ContentWarning{}, "appealed"); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := s.Posts.GetById(ctx, post.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ContentWarning != "" || got.Sensitive || got.ContentWarningForced {
+			t.Errorf("expected no warning; got %q, sensitive %t, forced %t", got.ContentWarning, got.Sensitive, got.ContentWarningForced)
+		}
+
+		if n := countTest(t, db, `SELECT COUNT(*) FROM moderation_log WHERE post_id = $1`, post.ID); n != 2 {
+			t.Errorf("expected 2 log entries; got %d", n)
+		}
+	})
+
+	t.Run("should not find deleted posts", func(t *testing.T) {
+		deleted := createTestPost(t, s, &Post{UserID: author.ID})
+		if err := s.Posts.DeleteById(ctx, deleted.ID, deleted.Version, author.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := s.Moderation.SetContentWarning(ctx, deleted.ID, moderator.ID, ContentWarning{Sensitive: true}, "nsfw")
+		if err != ErrNotFound {
+			t.Errorf("expected ErrNotFound; got %v", err)
+		}
+
+		if n := countTest(t, db, `SELECT COUNT(*) FROM moderation_log WHERE post_id = $1`, deleted.ID); n != 0 {
+			t.Errorf("expected nothing logged; got %d entries", n)
+		}
+	})
+}
@@ -2,13 +2,16 @@ package store
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
 type PaginatedFeedQuery struct {
-	Limit              int      `json:"limit" validate:"gte=1,lte=20"`
+	Limit  int     `json:"limit" validate:"gte=1,lte=20"`
+	Cursor *Cursor `json:"-"`
+	// Offset is deprecated in favor of Cursor, and ignored along with it.
 	Offset             int      `json:"offset" validate:"gte=0"`
 	Sort               string   `json:"sort" validate:"oneof=asc desc"`
 	Tags               []string `json:"tags" validate:"max=5"`
@@ -38,6 +41,15 @@ func (pf *PaginatedFeedQuery) Parse(r *http.Request) error {
 		pf.Offset = o
 	}
 
+	cursor := rq.Get("cursor")
+	if cursor != "" {
+		c, err := DecodeCursor(cursor)
+		if err != nil {
+			return err
+		}
+		pf.Cursor = c
+	}
+
 	sort := rq.Get("sort")
 	if sort != "" {
 		pf.Sort = sort
@@ -45,7 +57,11 @@ func (pf *PaginatedFeedQuery) Parse(r *http.Request) error {
 
 	tags := rq.Get("tags")
 	if tags != "" {
-		pf.Tags = strings.Split(tags, ",")
+		t, err := NormalizeTags(strings.Split(tags, ","))
+		if err != nil {
+			return err
+		}
+		pf.Tags = t
 	} else {
 		pf.Tags = []string{}
 	}
@@ -76,3 +92,149 @@ func parseTime(s string) string {
 
 	return t.Format(time.DateTime)
 }
+
+type PaginatedPostsQuery struct {
+	Limit  int      `json:"limit" validate:"gte=1,lte=20"`
+	Cursor *Cursor  `json:"-"`
+	Tags   []string `json:"tags" validate:"max=5"`
+	Since  string   `json:"since"`
+	Until  string   `json:"until"`
+}
+
+func (pq *PaginatedPostsQuery) Parse(r *http.Request) error {
+	rq := r.URL.Query()
+
+	limit := rq.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return err
+		}
+		pq.Limit = l
+	}
+
+	cursor := rq.Get("cursor")
+	if cursor != "" {
+		c, err := DecodeCursor(cursor)
+		if err != nil {
+			return err
+		}
+		pq.Cursor = c
+	}
+
+	tags := rq.Get("tags")
+	if tags != "" {
+		t, err := NormalizeTags(strings.Split(tags, ","))
+		if err != nil {
+			return err
+		}
+		pq.Tags = t
+	} else {
+		pq.Tags = []string{}
+	}
+
+	since := rq.Get("since")
+	if since != "" {
+		pq.Since = parseTime(since)
+	}
+
+	until := rq.Get("until")
+	if until != "" {
+		pq.Until = parseTime(until)
+	}
+
+	return nil
+}
+
+// PostsPage is one page of a cursor paginated post listing. NextCursor is
+// empty on the last page.
+type PostsPage struct {
+	Posts      []*PostForFeed `json:"posts"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// FeedPage is one page of the feed. NextCursor continues it in the order of
+// the feed and PrevCursor goes back. Both are empty when there is nothing
+// more that way, except on the first page, where PrevCursor is kept to fetch
+// the posts that arrive in the meantime.
+type FeedPage struct {
+	Posts      []*PostForFeed `json:"posts"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	PrevCursor string         `json:"prev_cursor,omitempty"`
+}
+
+// newFeedPage builds the page of posts fetched with limit+1 rows from
+// cursor, in the order they were fetched: backwards when cursor.Before is
+// set.
+func newFeedPage(posts []*PostForFeed, limit int, cursor *Cursor) *FeedPage {
+	before := cursor != nil && cursor.Before
+
+	more := len(posts) > limit
+	if more {
+		posts = posts[:limit]
+	}
+
+	if before {
+		slices.Reverse(posts)
+	}
+
+	page := &FeedPage{Posts: posts}
+	if page.Posts == nil {
+		page.Posts = []*PostForFeed{}
+	}
+
+	if len(posts) == 0 {
+		// Nothing is left that way, but the way back is still open.
+		if cursor != nil {
+			back := *cursor
+			back.Before = !before
+			if before {
+				page.NextCursor = back.Encode()
+			} else {
+				page.PrevCursor = back.Encode()
+			}
+		}
+		return page
+	}
+
+	first, last := posts[0].cursor, posts[len(posts)-1].cursor
+	first.Before = true
+
+	if more || before {
+		page.NextCursor = last.Encode()
+	}
+
+	if !before || more {
+		page.PrevCursor = first.Encode()
+	}
+
+	return page
+}
+
+// newPostsPage expects posts to have been fetched with limit+1 rows, so an
+// extra row means there is another page after this one.
+func newPostsPage(posts []*PostForFeed, limit int) *PostsPage {
+	page := &PostsPage{Posts: posts}
+
+	if len(posts) > limit {
+		page.Posts = posts[:limit]
+
+		page.NextCursor = page.Posts[limit-1].cursor.Encode()
+	}
+
+	if page.Posts == nil {
+		page.Posts = []*PostForFeed{}
+	}
+
+	return page
+}
+
+// nullIfEmpty lets optional string filters be bound as NULL so queries can
+// test them with IS NULL.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
@@ -0,0 +1,77 @@
+package store
+
+import "testing"
+
+func TestNewFeedPage(t *testing.T) {
+	posts := func(ids ...int64) []*PostForFeed {
+		feed := make([]*PostForFeed, len(ids))
+		for i, id := range ids {
+			feed[i] = &PostForFeed{Post: Post{ID: id}, cursor: Cursor{CreatedAt: "2025-01-11T10:00:00Z", ID: id}}
+		}
+		return feed
+	}
+
+	decode := func(t *testing.T, s string) *Cursor {
+		t.Helper()
+		if s == "" {
+			return nil
+		}
+		c, err := DecodeCursor(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	t.Run("should link the first page both ways when there is more", func(t *testing.T) {
+		page := newFeedPage(posts(5, 4, 3), 2, nil)
+
+		if len(page.Posts) != 2 || page.Posts[0].ID != 5 || page.Posts[1].ID != 4 {
+			t.Fatalf("unexpected posts %v", page.Posts)
+		}
+
+		if next := decode(t, page.NextCursor); next == nil || next.ID != 4 || next.Before {
+			t.Errorf("expected a next cursor after 4; got %+v", next)
+		}
+
+		if prev := decode(t, page.PrevCursor); prev == nil || prev.ID != 5 || !prev.Before {
+			t.Errorf("expected a prev cursor before 5; got %+v", prev)
+		}
+	})
+
+	t.Run("should end the last page", func(t *testing.T) {
+		page := newFeedPage(posts(2, 1), 2, &Cursor{CreatedAt: "2025-01-11T10:00:00Z", ID: 3})
+
+		if page.NextCursor != "" {
+			t.Errorf("expected no next cursor; got %q", page.NextCursor)
+		}
+	})
+
+	t.Run("should put pages read backwards back in order", func(t *testing.T) {
+		page := newFeedPage(posts(6, 7), 2, &Cursor{CreatedAt: "2025-01-11T10:00:00Z", ID: 5, Before: true})
+
+		if len(page.Posts) != 2 || page.Posts[0].ID != 7 || page.Posts[1].ID != 6 {
+			t.Fatalf("unexpected posts %v", page.Posts)
+		}
+
+		if next := decode(t, page.NextCursor); next == nil || next.ID != 6 || next.Before {
+			t.Errorf("expected a next cursor after 6; got %+v", next)
+		}
+
+		if page.PrevCursor != "" {
+			t.Errorf("expected no prev cursor; got %q", page.PrevCursor)
+		}
+	})
+
+	t.Run("should keep the way back from an empty page", func(t *testing.T) {
+		page := newFeedPage(nil, 2, &Cursor{CreatedAt: "2025-01-11T10:00:00Z", ID: 5, Before: true})
+
+		if len(page.Posts) != 0 || page.PrevCursor != "" {
+			t.Fatalf("unexpected page %+v", page)
+		}
+
+		if next := decode(t, page.NextCursor); next == nil || next.ID != 5 || next.Before {
+			t.Errorf("expected a next cursor after 5; got %+v", next)
+		}
+	})
+}
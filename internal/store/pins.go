@@ -0,0 +1,208 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/lib/pq"
+)
+
+// MaxPinnedPosts is how many posts a user can pin to their profile.
+const MaxPinnedPosts = 3
+
+var (
+	ErrPinLimit        = errors.New("too many pinned posts")
+	ErrNotPinnable     = errors.New("only published public posts can be pinned")
+	ErrInvalidPinOrder = errors.New("order must list every pinned post once")
+)
+
+type PinsStore struct {
+	db *sql.DB
+}
+
+func NewPinsStore(db *sql.DB) *PinsStore {
+	return &PinsStore{db: db}
+}
+
+// Pin pins postId, which must be a published public post of userId, after
+// the other pinned posts of userId. Pinning a pinned post is a no-op.
+func (s *PinsStore) Pin(ctx context.Context, userId, postId int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		// Locking the user serializes pins, so the limit holds.
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userId); err != nil {
+			return err
+		}
+
+		query := `
+			SELECT
+				p.visibility = 'public' AND p.status = 'published',
+				EXISTS (SELECT 1 FROM pinned_posts pp WHERE pp.post_id = p.id),
+				(SELECT COUNT(*) FROM pinned_posts pp WHERE pp.user_id = $2)
+			FROM posts p
+			WHERE p.id = $1 AND p.user_id = $2 AND p.deleted_at IS NULL
+		`
+
+		var pinnable, pinned bool
+		var count int
+
+		err := tx.QueryRowContext(ctx, query, postId, userId).Scan(&pinnable, &pinned, &count)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		switch {
+		case pinned:
+			return nil
+		case !pinnable:
+			return ErrNotPinnable
+		case count >= MaxPinnedPosts:
+			return ErrPinLimit
+		}
+
+		query = `
+			INSERT INTO pinned_posts (user_id, post_id, position)
+			VALUES ($1, $2, (SELECT COALESCE(MAX(position) + 1, 0) FROM pinned_posts WHERE user_id = $1))
+		`
+
+		_, err = tx.ExecContext(ctx, query, userId, postId)
+
+		return err
+	})
+}
+
+// Unpin unpins postId from the profile of userId. Unpinning a post that is
+// not pinned is a no-op.
+func (s *PinsStore) Unpin(ctx context.Context, userId, postId int64) error {
+	query := `DELETE FROM pinned_posts WHERE user_id = $1 AND post_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userId, postId)
+
+	return err
+}
+
+// Reorder sets the order of the pinned posts of userId. postIds must list
+// every pinned post exactly once, otherwise ErrInvalidPinOrder is returned.
+func (s *PinsStore) Reorder(ctx context.Context, userId int64, postIds []int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		query := `SELECT post_id FROM pinned_posts WHERE user_id = $1 FOR UPDATE`
+
+		rows, err := tx.QueryContext(ctx, query, userId)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var pinned []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			pinned = append(pinned, id)
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		order := slices.Clone(postIds)
+		slices.Sort(order)
+		slices.Sort(pinned)
+
+		if !slices.Equal(order, pinned) {
+			return ErrInvalidPinOrder
+		}
+
+		query = `
+			UPDATE pinned_posts pp SET position = o.n - 1
+			FROM unnest($2::bigint[]) WITH ORDINALITY AS o(post_id, n)
+			WHERE pp.user_id = $1 AND pp.post_id = o.post_id
+		`
+
+		_, err = tx.ExecContext(ctx, query, userId, pq.Array(postIds))
+
+		return err
+	})
+}
+
+// getPinned returns the pinned posts of userId that match the filters of
+// ppq, in their order, as seen by viewerId.
+func (s *PostsStore) getPinned(ctx context.Context, userId, viewerId int64, ppq PaginatedPostsQuery) ([]*PostForFeed, error) {
+	query := `
+		SELECT ` + feedSelect{viewer: "$5", sortKey: "p.created_at, p.id"}.columns() + `
+		FROM pinned_posts pp
+		JOIN posts p ON p.id = pp.post_id
+		JOIN users u ON p.user_id = u.id
+		WHERE
+			pp.user_id = $1 AND
+			` + visibleTo("p", "$5") + ` AND
+			(p.tags @> ` + canonicalTags("$2") + ` OR $2 = '{}') AND
+			($3::timestamptz IS NULL OR p.created_at >= $3) AND
+			($4::timestamptz IS NULL OR p.created_at <= $4)
+		ORDER BY pp.position, pp.post_id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userId,
+		pq.Array(ppq.Tags),
+		nullIfEmpty(ppq.Since),
+		nullIfEmpty(ppq.Until),
+		viewerId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := scanPostsForFeed(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+		post.Pinned = true
+	}
+
+	return posts, nil
+}
+
+// unpinHidden unpins postId if it is no longer shown to everyone visiting
+// the profile of its author, because it was deleted, unpublished or made
+// non-public.
+func unpinHidden(ctx context.Context, tx *sql.Tx, postId int64) error {
+	query := `
+		DELETE FROM pinned_posts pp
+		USING posts p
+		WHERE
+			pp.post_id = $1 AND
+			p.id = pp.post_id AND
+			(p.deleted_at IS NOT NULL OR p.visibility <> 'public' OR p.status <> 'published')
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, postId)
+
+	return err
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestPins(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	other := createTestUser(t, db, "other", "")
+
+	pinned := func(t *testing.T) []int64 {
+		t.Helper()
+
+		rows, err := db.Query(`SELECT post_id FROM pinned_posts WHERE user_id = $1 ORDER BY position`, author.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		return ids
+	}
+
+	var posts []*Post
+	for range MaxPinnedPosts + 1 {
+		posts = append(posts, createTestPost(t, s, &Post{UserID: author.ID}))
+	}
+
+	t.Run("should pin up to the limit", func(t *testing.T) {
+		for _, post := range posts[:MaxPinnedPosts] {
+			if err := s.Pins.Pin(ctx, author.ID, post.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := s.Pins.Pin(ctx, author.ID, posts[MaxPinnedPosts].ID); err != ErrPinLimit {
+			t.Errorf("expected ErrPinLimit; got %v", err)
+		}
+
+		if err := s.Pins.Pin(ctx, author.ID, posts[0].ID); err != nil {
+			t.Errorf("expected pinning a pinned post to be a no-op; got %v", err)
+		}
+
+		expected := []int64{posts[0].ID, posts[1].ID, posts[2].ID}
+		if got := pinned(t); !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+	})
+
+	t.Run("should reorder every pinned post", func(t *testing.T) {
+		if err := s.Pins.Reorder(ctx, author.ID, []int64{posts[0].ID, posts[1].ID}); err != ErrInvalidPinOrder {
+			t.Errorf("expected ErrInvalidPinOrder; got %v", err)
+		}
+
+		order := []int64{posts[2].ID, posts[0].ID, posts[1].ID}
+		if err := s.Pins.Reorder(ctx, author.ID, order); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := pinned(t); !slices.Equal(got, order) {
+			t.Errorf("expected %v; got %v", order, got)
+		}
+	})
+
+	t.Run("should unpin posts that are no longer public", func(t *testing.T) {
+		post := posts[0]
+		post.Visibility = VisibilityFollowers
+
+		if err := s.Posts.UpdateById(ctx, post, author.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := pinned(t); slices.Contains(got, post.ID) {
+			t.Errorf("expected %d to be unpinned; got %v", post.ID, got)
+		}
+	})
+
+	t.Run("should unpin deleted posts", func(t *testing.T) {
+		post := posts[1]
+
+		if err := s.Posts.DeleteById(ctx, post.ID, post.Version, author.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := pinned(t); !slices.Equal(got, []int64{posts[2].ID}) {
+			t.Errorf("expected only %d to be left; got %v", posts[2].ID, got)
+		}
+	})
+
+	t.Run("should only pin published public posts of the user", func(t *testing.T) {
+		if err := s.Pins.Pin(ctx, author.ID, posts[0].ID); err != ErrNotPinnable {
+			t.Errorf("followers only: expected ErrNotPinnable; got %v", err)
+		}
+
+		draft := createTestPost(t, s, &Post{UserID: author.ID, Status: StatusDraft})
+		if err := s.Pins.Pin(ctx, author.ID, draft.ID); err != ErrNotPinnable {
+			t.Errorf("draft: expected ErrNotPinnable; got %v", err)
+		}
+
+		if err := s.Pins.Pin(ctx, author.ID, posts[1].ID); err != ErrNotFound {
+			t.Errorf("deleted: expected ErrNotFound; got %v", err)
+		}
+
+		foreign := createTestPost(t, s, &Post{UserID: other.ID})
+		if err := s.Pins.Pin(ctx, author.ID, foreign.ID); err != ErrNotFound {
+			t.Errorf("foreign: expected ErrNotFound; got %v", err)
+		}
+	})
+
+	t.Run("should hold the limit under concurrent pins", func(t *testing.T) {
+		var candidates []*Post
+		for range 5 {
+			candidates = append(candidates, createTestPost(t, s, &Post{UserID: other.ID}))
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, len(candidates))
+
+		for i, post := range candidates {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = s.Pins.Pin(ctx, other.ID, post.ID)
+			}()
+		}
+		wg.Wait()
+
+		limited := 0
+		for _, err := range errs {
+			switch {
+			case errors.Is(err, ErrPinLimit):
+				limited++
+			case err != nil:
+				t.Fatal(err)
+			}
+		}
+
+		if limited != len(candidates)-MaxPinnedPosts {
+			t.Errorf("expected %d pins over the limit; got %d", len(candidates)-MaxPinnedPosts, limited)
+		}
+
+		if n := countTest(t, db, `SELECT COUNT(*) FROM pinned_posts WHERE user_id = $1`, other.ID); n != MaxPinnedPosts {
+			t.Errorf("expected %d pinned posts; got %d", MaxPinnedPosts, n)
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrPollClosed  = errors.New("the poll is closed")
+	ErrInvalidVote = errors.New("invalid vote")
+)
+
+// Poll is attached to a post. The vote counts are nil while they are hidden
+// from the viewer: when HideResults is set, only the author and users who
+// voted see them before the poll closes.
+type Poll struct {
+	MultipleChoice bool         `json:"multiple_choice"`
+	HideResults    bool         `json:"hide_results"`
+	ClosesAt       *string      `json:"closes_at,omitempty"`
+	Closed         bool         `json:"closed"`
+	VotersCount    *int         `json:"voters_count,omitempty"`
+	Options        []PollOption `json:"options"`
+	// MyVotes are the ids of the options the viewer voted for.
+	MyVotes []int64 `json:"my_votes"`
+}
+
+type PollOption struct {
+	ID    int64  `json:"id"`
+	Text  string `json:"text"`
+	Votes *int   `json:"votes,omitempty"`
+}
+
+// pollJSON returns an SQL expression that builds the Poll of the post
+// aliased as post, as seen by the user bound to the viewer placeholder, or
+// NULL when the post has no poll.
+func pollJSON(post, viewer string) string {
+	return fmt.Sprintf(`(
+		SELECT json_build_object(
+			'multiple_choice', pl.multiple_choice,
+			'hide_results', pl.hide_results,
+			'closes_at', pl.closes_at,
+			'closed', COALESCE(pl.closes_at <= NOW(), false),
+			'voters_count', CASE WHEN results.visible THEN pl.voters_count END,
+			'options', (
+				SELECT json_agg(json_build_object(
+					'id', o.id,
+					'text', o.text,
+					'votes', CASE WHEN results.visible THEN o.votes_count END
+				) ORDER BY o.position)
+				FROM poll_options o WHERE o.post_id = pl.post_id
+			),
+			'my_votes', COALESCE((
+				SELECT json_agg(v.option_id ORDER BY v.option_id)
+				FROM poll_votes v WHERE v.post_id = pl.post_id AND v.user_id = %[2]s
+			), '[]')
+		)
+		FROM polls pl
+		CROSS JOIN LATERAL (
+			SELECT NOT pl.hide_results OR pl.closes_at <= NOW() OR %[1]s.user_id = %[2]s OR EXISTS (
+				SELECT 1 FROM poll_votes v WHERE v.post_id = pl.post_id AND v.user_id = %[2]s
+			) AS visible
+		) results
+		WHERE pl.post_id = %[1]s.id
+	)`, post, viewer)
+}
+
+// scanPoll decodes the result of pollJSON.
+func scanPoll(data []byte) (*Poll, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var poll Poll
+	if err := json.Unmarshal(data, &poll); err != nil {
+		return nil, err
+	}
+
+	return &poll, nil
+}
+
+type PollsStore struct {
+	db *sql.DB
+}
+
+func NewPollsStore(db *sql.DB) *PollsStore {
+	return &PollsStore{db: db}
+}
+
+// Get returns the poll of postId as seen by viewerId, or nil when the post
+// has no poll.
+func (s *PollsStore) Get(ctx context.Context, postId, viewerId int64) (*Poll, error) {
+	query := `SELECT ` + pollJSON("p", "$2") + ` FROM posts p WHERE p.id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var data []byte
+	if err := s.db.QueryRowContext(ctx, query, postId, viewerId).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return scanPoll(data)
+}
+
+// Vote makes optionIds the vote of userId in the poll of postId, replacing
+// any previous vote. The poll row is locked for the duration, so concurrent
+// votes are applied one after the other and the counters stay exact.
+func (s *PollsStore) Vote(ctx context.Context, postId, userId int64, optionIds []int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var multipleChoice, closed bool
+		query := `
+			SELECT multiple_choice, COALESCE(closes_at <= NOW(), false)
+			FROM polls WHERE post_id = $1
+			FOR UPDATE
+		`
+		if err := tx.QueryRowContext(ctx, query, postId).Scan(&multipleChoice, &closed); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if closed {
+			return ErrPollClosed
+		}
+
+		if len(optionIds) == 0 || (!multipleChoice && len(optionIds) > 1) {
+			return ErrInvalidVote
+		}
+
+		var known int
+		query = `SELECT COUNT(*) FROM poll_options WHERE post_id = $1 AND id = ANY($2)`
+		if err := tx.QueryRowContext(ctx, query, postId, pq.Array(optionIds)).Scan(&known); err != nil {
+			return err
+		}
+
+		if known != len(optionIds) {
+			return ErrInvalidVote
+		}
+
+		query = `
+			WITH removed AS (
+				DELETE FROM poll_votes WHERE post_id = $1 AND user_id = $2
+				RETURNING option_id
+			)
+			UPDATE poll_options o SET votes_count = o.votes_count - 1
+			FROM removed r WHERE o.id = r.option_id
+		`
+		res, err := tx.ExecContext(ctx, query, postId, userId)
+		if err != nil {
+			return err
+		}
+
+		removed, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		query = `
+			INSERT INTO poll_votes (post_id, user_id, option_id)
+			SELECT $1, $2, unnest($3::bigint[])
+		`
+		if _, err := tx.ExecContext(ctx, query, postId, userId, pq.Array(optionIds)); err != nil {
+			return err
+		}
+
+		query = `UPDATE poll_options SET votes_count = votes_count + 1 WHERE id = ANY($1)`
+		if _, err := tx.ExecContext(ctx, query, pq.Array(optionIds)); err != nil {
+			return err
+		}
+
+		if removed > 0 {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE polls SET voters_count = voters_count + 1 WHERE post_id = $1`, postId)
+
+		return err
+	})
+}
+
+// createPoll attaches poll to postId, filling in the ids of its options.
+func createPoll(ctx context.Context, tx *sql.Tx, postId int64, poll *Poll) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+		INSERT INTO polls (post_id, multiple_choice, hide_results, closes_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.ExecContext(ctx, query, postId, poll.MultipleChoice, poll.HideResults, poll.ClosesAt); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO poll_options (post_id, position, text) VALUES ($1, $2, $3) RETURNING id`
+
+	for i := range poll.Options {
+		option := &poll.Options[i]
+		if err := tx.QueryRowContext(ctx, query, postId, i, option.Text).Scan(&option.ID); err != nil {
+			return err
+		}
+
+		votes := 0
+		option.Votes = &votes
+	}
+
+	voters := 0
+	poll.VotersCount = &voters
+	poll.MyVotes = []int64{}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestPollVotes(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	alice := createTestUser(t, db, "alice", "")
+	bob := createTestUser(t, db, "bob", "")
+
+	newPoll := func(multipleChoice, hideResults bool) (*Post, []int64) {
+		post := createTestPost(t, s, &Post{
+			UserID: author.ID,
+			Poll: &Poll{
+				MultipleChoice: multipleChoice,
+				HideResults:    hideResults,
+				Options:        []PollOption{{Text: "a"}, {Text: "b"}, {Text: "c"}},
+			},
+		})
+
+		var ids []int64
+		for _, option := range post.Poll.Options {
+			ids = append(ids, option.ID)
+		}
+
+		return post, ids
+	}
+
+	// votes returns the vote counts of the poll of post, in option order,
+	// and its voters count, as seen by its author.
+	votes := func(t *testing.T, post *Post) ([]int, int) {
+		t.Helper()
+
+		poll, err := s.Polls.Get(ctx, post.ID, author.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var counts []int
+		for _, option := range poll.Options {
+			counts = append(counts, *option.Votes)
+		}
+
+		return counts, *poll.VotersCount
+	}
+
+	vote := func(t *testing.T, post *Post, user *User, optionIds ...int64) {
+		t.Helper()
+
+		if err := s.Polls.Vote(ctx, post.ID, user.ID, optionIds); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("should count votes and vote changes", func(t *testing.T) {
+		post, options := newPoll(false, false)
+
+		steps := []struct {
+			user     *User
+			option   int64
+			expected []int
+			voters   int
+		}{
+			{alice, options[0], []int{1, 0, 0}, 1},
+			{alice, options[1], []int{0, 1, 0}, 1},
+			{alice, options[1], []int{0, 1, 0}, 1},
+			{bob, options[1], []int{0, 2, 0}, 2},
+		}
+
+		for i, step := range steps {
+			vote(t, post, step.user, step.option)
+
+			counts, voters := votes(t, post)
+			if !slices.Equal(counts, step.expected) || voters != step.voters {
+				t.Errorf("step %d: expected %v by %d voters; got %v by %d", i, step.expected, step.voters, counts, voters)
+			}
+		}
+
+		poll, err := s.Polls.Get(ctx, post.ID, alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(poll.MyVotes, []int64{options[1]}) {
+			t.Errorf("expected my votes to be %v; got %v", options[1:2], poll.MyVotes)
+		}
+	})
+
+	t.Run("should reject invalid votes", func(t *testing.T) {
+		post, options := newPoll(false, false)
+		_, otherOptions := newPoll(false, false)
+
+		for name, optionIds := range map[string][]int64{
+			"none":                   {},
+			"several in single vote": options[:2],
+			"another poll's option":  otherOptions[:1],
+		} {
+			if err := s.Polls.Vote(ctx, post.ID, alice.ID, optionIds); err != ErrInvalidVote {
+				t.Errorf("%s: expected ErrInvalidVote; got %v", name, err)
+			}
+		}
+
+		if counts, voters := votes(t, post); !slices.Equal(counts, []int{0, 0, 0}) || voters != 0 {
+			t.Errorf("expected no votes; got %v by %d", counts, voters)
+		}
+	})
+
+	t.Run("should count every choice of multiple choice votes", func(t *testing.T) {
+		post, options := newPoll(true, false)
+
+		vote(t, post, alice, options[0], options[2])
+		vote(t, post, bob, options[2])
+
+		if counts, voters := votes(t, post); !slices.Equal(counts, []int{1, 0, 2}) || voters != 2 {
+			t.Errorf("expected [1 0 2] by 2 voters; got %v by %d", counts, voters)
+		}
+
+		vote(t, post, alice, options[1])
+
+		if counts, voters := votes(t, post); !slices.Equal(counts, []int{0, 1, 1}) || voters != 2 {
+			t.Errorf("expected [0 1 1] by 2 voters; got %v by %d", counts, voters)
+		}
+	})
+
+	t.Run("should refuse votes once closed", func(t *testing.T) {
+		post, options := newPoll(false, false)
+
+		execTest(t, db, `UPDATE polls SET closes_at = NOW() - interval '1 minute' WHERE post_id = $1`, post.ID)
+
+		if err := s.Polls.Vote(ctx, post.ID, alice.ID, options[:1]); err != ErrPollClosed {
+			t.Errorf("expected ErrPollClosed; got %v", err)
+		}
+	})
+
+	t.Run("should not find posts without polls", func(t *testing.T) {
+		post := createTestPost(t, s, &Post{UserID: author.ID})
+
+		if err := s.Polls.Vote(ctx, post.ID, alice.ID, []int64{1}); err != ErrNotFound {
+			t.Errorf("expected ErrNotFound; got %v", err)
+		}
+	})
+
+	t.Run("should hide results until the viewer votes", func(t *testing.T) {
+		post, options := newPoll(false, true)
+
+		vote(t, post, bob, options[0])
+
+		poll, err := s.Polls.Get(ctx, post.ID, alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if poll.VotersCount != nil || poll.Options[0].Votes != nil {
+			t.Errorf("expected hidden results; got %+v", poll)
+		}
+
+		vote(t, post, alice, options[1])
+
+		if counts, voters := votes(t, post); !slices.Equal(counts, []int{1, 1, 0}) || voters != 2 {
+			t.Errorf("expected [1 1 0] by 2 voters; got %v by %d", counts, voters)
+		}
+
+		poll, err = s.Polls.Get(ctx, post.ID, alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if poll.VotersCount == nil || *poll.VotersCount != 2 {
+			t.Errorf("expected the results once voted; got %+v", poll)
+		}
+	})
+
+	t.Run("should keep counts exact under concurrent votes", func(t *testing.T) {
+		post, options := newPoll(false, false)
+
+		var voters []*User
+		for i := range 10 {
+			voters = append(voters, createTestUser(t, db, fmt.Sprintf("voter%d", i), ""))
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 2*len(voters))
+
+		for _, voter := range voters {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				// Every voter changes their vote, racing the others.
+				for _, option := range []int64{options[0], options[2]} {
+					errs <- s.Polls.Vote(ctx, post.ID, voter.ID, []int64{option})
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if counts, n := votes(t, post); !slices.Equal(counts, []int{0, 0, 10}) || n != 10 {
+			t.Errorf("expected [0 0 10] by 10 voters; got %v by %d", counts, n)
+		}
+	})
+}
@@ -3,27 +3,142 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 
+	"github.com/DenysBahachuk/gopher_social/internal/markdown"
 	"github.com/lib/pq"
 )
 
+var ErrUnknownMention = errors.New("mentioned user does not exist")
+
 type Post struct {
-	ID        int64     `json:"id"`
-	Content   string    `json:"content"`
-	Title     string    `json:"title"`
-	UserID    int64     `json:"user_id"`
-	Tags      []string  `json:"tags"`
-	CreatedAt string    `json:"created_at"`
-	UpdatedAt string    `json:"updated_at"`
-	Comments  []Comment `json:"comments"`
-	Version   int       `json:"version"`
-	User      User      `json:"user"`
+	ID        int64      `json:"id"`
+	Content   string     `json:"content"`
+	Title     string     `json:"title"`
+	UserID    int64      `json:"user_id"`
+	Tags      []string   `json:"tags"`
+	CreatedAt string     `json:"created_at"`
+	UpdatedAt string     `json:"updated_at"`
+	Comments  []*Comment `json:"comments"`
+	Version   int        `json:"version"`
+	User      User       `json:"user"`
+
+	// Comments is only set on single posts, to the first page of their
+	// comments. CommentsNextCursor continues it.
+	CommentsCount      int    `json:"comments_count"`
+	CommentsNextCursor string `json:"comments_next_cursor,omitempty"`
+
+	// ContentHTML is Content rendered from Markdown and sanitized.
+	ContentHTML string `json:"content_html"`
+
+	// ContentWarning is shown in place of the content until the reader
+	// chooses to see it. Sensitive flags the post as not safe for everyone
+	// even without a warning. ContentWarningForced is set when a moderator
+	// applied them, and the author can no longer lift them.
+	ContentWarning       string `json:"content_warning,omitempty"`
+	Sensitive            bool   `json:"sensitive"`
+	ContentWarningForced bool   `json:"content_warning_forced,omitempty"`
+
+	// Visibility is one of VisibilityPublic, VisibilityFollowers or
+	// VisibilityMentioned. Mentioned-only posts are visible to the users in
+	// MentionedUserIDs.
+	Visibility       string  `json:"visibility"`
+	MentionedUserIDs []int64 `json:"mentioned_user_ids,omitempty"`
+
+	// CommentPolicy is who may comment: one of CommentPolicyEveryone,
+	// CommentPolicyFollowers, CommentPolicyMentioned or CommentPolicyOff.
+	// CommentsLocked stops new comments whatever the policy.
+	CommentPolicy  string `json:"comment_policy"`
+	CommentsLocked bool   `json:"comments_locked"`
+
+	// Entities are the hashtags and mentions in Content. Mentioned users
+	// are recorded alongside MentionedUserIDs, and so can see the post too.
+	Entities Entities `json:"entities"`
+
+	// Status is one of StatusDraft, StatusScheduled or StatusPublished.
+	// Scheduled posts are published at PublishAt by PublishDue.
+	Status    string  `json:"status"`
+	PublishAt *string `json:"publish_at,omitempty"`
+
+	ReactionCounts ReactionCounts `json:"reaction_counts"`
+	MyReaction     string         `json:"my_reaction,omitempty"`
+
+	// QuotedPost is nil when the quoted post was deleted or is hidden from
+	// the viewer, even though QuotedPostID is set.
+	QuotedPostID *int64      `json:"quoted_post_id,omitempty"`
+	QuotedPost   *QuotedPost `json:"quoted_post,omitempty"`
+
+	Poll *Poll `json:"poll,omitempty"`
+
+	// LinkURL is the first URL in Content. Its LinkPreview is fetched in the
+	// background, and stays nil until then or when the page has none.
+	LinkURL     *string      `json:"link_url,omitempty"`
+	LinkPreview *LinkPreview `json:"link_preview,omitempty"`
+
+	// ReplyToPostID is the post this one replies to, and ThreadID the post
+	// that started the conversation. Both are nil on posts that are not
+	// replies.
+	ReplyToPostID *int64 `json:"reply_to_post_id,omitempty"`
+	ThreadID      *int64 `json:"thread_id,omitempty"`
+}
+
+// contentHTML caches the renderings of post contents by post version, as
+// every change to the content bumps the version.
+var contentHTML = markdown.NewCache[postVersion](4096)
+
+type postVersion struct {
+	id      int64
+	version int
+}
+
+func (p *Post) renderContent() {
+	p.ContentHTML = contentHTML.Render(postVersion{p.ID, p.Version}, p.Content)
+}
+
+// QuotedPost is the compact view of a post embedded in the posts quoting it.
+type QuotedPost struct {
+	ID        int64  `json:"id"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+	User      User   `json:"user"`
+
+	ContentWarning string `json:"content_warning,omitempty"`
+	Sensitive      bool   `json:"sensitive"`
+}
+
+func NewQuotedPost(post *Post) *QuotedPost {
+	return &QuotedPost{
+		ID:             post.ID,
+		Title:          post.Title,
+		Content:        post.Content,
+		CreatedAt:      post.CreatedAt,
+		User:           post.User,
+		ContentWarning: post.ContentWarning,
+		Sensitive:      post.Sensitive,
+	}
 }
 
 type PostForFeed struct {
 	Post
-	CommentsCount int `json:"comments_count"`
+	RepliesCount int `json:"replies_count"`
+
+	// RepostedBy is set on feed items that are there because a followed
+	// user reposted them.
+	RepostedBy *User `json:"reposted_by,omitempty"`
+
+	// Pinned is set on the pinned posts listed first on profiles.
+	Pinned bool `json:"pinned,omitempty"`
+
+	// ContentDisplay is how the viewer asked for posts with a content
+	// warning or flagged sensitive to be shown: one of DisplayShow,
+	// DisplayBlur or DisplayHide. It is always DisplayShow for other posts
+	// and for the posts of the viewer.
+	ContentDisplay string `json:"content_display"`
+
+	// cursor is the position of the post in the listing it was read from.
+	cursor Cursor
 }
 
 type PostsStore struct {
@@ -36,40 +151,69 @@ func NewPostsStore(db *sql.DB) *PostsStore {
 	}
 }
 
-func (s *PostsStore) GetUserFeed(ctx context.Context, userId int64, pfq PaginatedFeedQuery) ([]*PostForFeed, error) {
-	orderBy := "DESC" // Default sort order
-	if pfq.Sort == "asc" {
-		orderBy = "ASC"
+// feedSelect builds the select list shared by every query that returns
+// posts in the PostForFeed shape. Such queries alias posts as p and users as u
+// and scan their rows with scanPostsForFeed.
+type feedSelect struct {
+	// viewer is the placeholder bound to the id of the user the posts are
+	// shown to.
+	viewer string
+	// sortKey is the (timestamp, id) pair the listing is ordered by.
+	sortKey string
+	// repostedBy is the id of the reposting user, if the listing has one.
+	repostedBy string
+}
+
+func (fs feedSelect) columns() string {
+	repostedBy := fs.repostedBy
+	if repostedBy == "" {
+		repostedBy = "NULL::bigint"
 	}
 
-	query := `
-		SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.version, p.tags, u.username,
-		COUNT(c.id) as comments_count 
-		FROM posts p 
-		LEFT JOIN comments c ON c.post_id = p.id 
-		LEFT JOIN users u ON p.user_id = u.id 
-		JOIN followers f ON f.follower_id = p.user_id OR p.user_id = $1
-		WHERE 
-			f.user_id = $1 AND 
-			(p.title ILIKE '%' || $4 || '%' OR p.content ILIKE '%' || $4 || '%') AND 
-			(p.tags @> $5 OR $5 = '{}')
-		GROUP BY p.id, u.username
-		ORDER BY p.created_at ` + orderBy + `
-		LIMIT $2 OFFSET $3
+	return `
+		p.id, p.user_id, p.title, p.content, p.created_at, p.version, p.tags, p.visibility,
+		p.status, p.publish_at, p.entities, u.username,
+		p.content_warning, p.sensitive, p.content_warning_forced, p.comment_policy, p.comments_locked,
+		` + contentDisplay("p", fs.viewer) + ` AS content_display,
+		(
+			SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL AND c.hidden_at IS NULL
+		) AS comments_count,
+		` + repliesCount("p") + ` AS replies_count,
+		p.reply_to_post_id, p.thread_id,
+		p.reaction_counts,
+		COALESCE((
+			SELECT r.kind FROM post_reactions r WHERE r.post_id = p.id AND r.user_id = ` + fs.viewer + `
+		), '') AS my_reaction,
+		p.quoted_post_id,
+		(
+			SELECT json_build_object(
+				'id', q.id, 'title', q.title, 'content', q.content, 'created_at', q.created_at,
+				'user', json_build_object('id', qu.id, 'username', qu.username),
+				'content_warning', q.content_warning, 'sensitive', q.sensitive
+			)
+			FROM posts q JOIN users qu ON qu.id = q.user_id
+			WHERE q.id = p.quoted_post_id AND ` + visibleTo("q", fs.viewer) + `
+		) AS quoted_post,
+		` + pollJSON("p", fs.viewer) + ` AS poll,
+		p.link_url, ` + linkPreviewJSON("p") + ` AS link_preview,
+		` + repostedBy + ` AS reposted_by_id,
+		(SELECT rb.username FROM users rb WHERE rb.id = ` + repostedBy + `) AS reposted_by_username,
+		` + fs.sortKey + `
 	`
+}
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
-	defer cancel()
-
-	rows, err := s.db.QueryContext(ctx, query, userId, pfq.Limit, pfq.Offset, pfq.TitleContentSearch, pq.Array(pfq.Tags))
-	if err != nil {
-		return nil, err
-	}
+func scanPostsForFeed(rows *sql.Rows) ([]*PostForFeed, error) {
+	defer rows.Close()
 
 	var feed []*PostForFeed
 
 	for rows.Next() {
 		var post PostForFeed
+		var quotedPost []byte
+		var poll []byte
+		var linkPreview []byte
+		var repostedByID sql.NullInt64
+		var repostedByUsername sql.NullString
 
 		if err := rows.Scan(
 			&post.ID,
@@ -79,39 +223,328 @@ func (s *PostsStore) GetUserFeed(ctx context.Context, userId int64, pfq Paginate
 			&post.CreatedAt,
 			&post.Version,
 			pq.Array(&post.Tags),
+			&post.Visibility,
+			&post.Status,
+			&post.PublishAt,
+			&post.Entities,
 			&post.User.Username,
+			&post.ContentWarning,
+			&post.Sensitive,
+			&post.ContentWarningForced,
+			&post.CommentPolicy,
+			&post.CommentsLocked,
+			&post.ContentDisplay,
 			&post.CommentsCount,
+			&post.RepliesCount,
+			&post.ReplyToPostID,
+			&post.ThreadID,
+			&post.ReactionCounts,
+			&post.MyReaction,
+			&post.QuotedPostID,
+			&quotedPost,
+			&poll,
+			&post.LinkURL,
+			&linkPreview,
+			&repostedByID,
+			&repostedByUsername,
+			&post.cursor.CreatedAt,
+			&post.cursor.ID,
 		); err != nil {
 			return nil, err
 		}
 
+		post.User.ID = post.UserID
+		post.renderContent()
+
+		if quotedPost != nil {
+			if err := json.Unmarshal(quotedPost, &post.QuotedPost); err != nil {
+				return nil, err
+			}
+		}
+
+		p, err := scanPoll(poll)
+		if err != nil {
+			return nil, err
+		}
+		post.Poll = p
+
+		if linkPreview != nil {
+			if err := json.Unmarshal(linkPreview, &post.LinkPreview); err != nil {
+				return nil, err
+			}
+		}
+
+		if repostedByID.Valid {
+			post.RepostedBy = &User{ID: repostedByID.Int64, Username: repostedByUsername.String}
+		}
+
 		feed = append(feed, &post)
 	}
 
-	return feed, nil
+	return feed, rows.Err()
 }
 
+// GetUserFeed returns a page of the feed of userId. It is paged through
+// with pfq.Cursor, or with the deprecated pfq.Offset when there is no cursor.
+func (s *PostsStore) GetUserFeed(ctx context.Context, userId int64, pfq PaginatedFeedQuery) (*FeedPage, error) {
+	orderBy, after := "DESC", "<" // Default sort order
+	if pfq.Sort == "asc" {
+		orderBy, after = "ASC", ">"
+	}
+
+	offset := pfq.Offset
+	var cursorActivityAt any
+	var cursorID int64
+	if pfq.Cursor != nil {
+		offset = 0
+		cursorActivityAt = pfq.Cursor.CreatedAt
+		cursorID = pfq.Cursor.ID
+
+		// Pages before the cursor are read backwards from it, and put back
+		// in order by newFeedPage.
+		if pfq.Cursor.Before {
+			orderBy, after = "ASC", ">"
+			if pfq.Sort == "asc" {
+				orderBy, after = "DESC", "<"
+			}
+		}
+	}
+
+	fs := feedSelect{viewer: "$1", sortKey: "fi.activity_at, p.id", repostedBy: "fi.reposted_by"}
+
+	// Posts of followed users, posts with followed tags and posts reposted
+	// by followed users are collapsed into one item per post, keeping its
+	// most recent activity: the newest repost by a followed user, or else
+	// the post itself. Each branch picks its items with NOT EXISTS rather
+	// than deduplicating the whole feed, so the cursor bound applies before
+	// the items are sorted.
+	query := `
+		WITH feed_items AS (
+			SELECT p.id AS post_id, p.created_at AS activity_at, NULL::bigint AS reposted_by
+			FROM posts p
+			WHERE
+				(
+					p.user_id = $1 OR
+					EXISTS (SELECT 1 FROM followers f WHERE f.user_id = $1 AND f.follower_id = p.user_id) OR
+					p.tags && ARRAY(SELECT tf.tag FROM tag_follows tf WHERE tf.user_id = $1)
+				) AND
+				NOT EXISTS (
+					SELECT 1 FROM reposts r
+					JOIN followers f ON f.follower_id = r.user_id AND f.user_id = $1
+					WHERE r.post_id = p.id
+				) AND
+				($6::timestamptz IS NULL OR (p.created_at, p.id) ` + after + ` ($6, $7))
+			UNION ALL
+			SELECT rp.post_id, rp.created_at, rp.user_id
+			FROM reposts rp
+			WHERE
+				EXISTS (SELECT 1 FROM followers f WHERE f.user_id = $1 AND f.follower_id = rp.user_id) AND
+				NOT EXISTS (
+					SELECT 1 FROM reposts r
+					JOIN followers f ON f.follower_id = r.user_id AND f.user_id = $1
+					WHERE r.post_id = rp.post_id AND (r.created_at, r.user_id) > (rp.created_at, rp.user_id)
+				) AND
+				($6::timestamptz IS NULL OR (rp.created_at, rp.post_id) ` + after + ` ($6, $7))
+		)
+		SELECT ` + fs.columns() + `
+		FROM feed_items fi
+		JOIN posts p ON p.id = fi.post_id
+		JOIN users u ON p.user_id = u.id 
+		WHERE 
+			` + visibleTo("p", "$1") + ` AND
+			(p.title ILIKE '%' || $4 || '%' OR p.content ILIKE '%' || $4 || '%') AND 
+			(p.tags @> ` + canonicalTags("$5") + ` OR $5 = '{}')
+		ORDER BY fi.activity_at ` + orderBy + `, p.id ` + orderBy + `
+		LIMIT $2 OFFSET $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userId,
+		pfq.Limit+1,
+		offset,
+		pfq.TitleContentSearch,
+		pq.Array(pfq.Tags),
+		cursorActivityAt,
+		cursorID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := scanPostsForFeed(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFeedPage(posts, pfq.Limit, pfq.Cursor), nil
+}
+
+// GetByUser returns the posts authored by userId as seen by viewerId: the
+// pinned ones first, in their order, then the others newest first. Only
+// published posts that aren't deleted and that viewerId may see are listed;
+// drafts and scheduled posts are fetched with GetDrafts.
+func (s *PostsStore) GetByUser(ctx context.Context, userId, viewerId int64, ppq PaginatedPostsQuery) (*PostsPage, error) {
+	query := `
+		SELECT ` + feedSelect{viewer: "$8", sortKey: "p.created_at, p.id"}.columns() + `
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE
+			p.user_id = $1 AND
+			` + visibleTo("p", "$8") + ` AND
+			(p.tags @> ` + canonicalTags("$2") + ` OR $2 = '{}') AND
+			($3::timestamptz IS NULL OR p.created_at >= $3) AND
+			($4::timestamptz IS NULL OR p.created_at <= $4) AND
+			($5::timestamptz IS NULL OR (p.created_at, p.id) < ($5, $6)) AND
+			NOT EXISTS (SELECT 1 FROM pinned_posts pp WHERE pp.post_id = p.id)
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $7
+	`
+
+	var cursorCreatedAt any
+	var cursorID int64
+	if ppq.Cursor != nil {
+		cursorCreatedAt = ppq.Cursor.CreatedAt
+		cursorID = ppq.Cursor.ID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userId,
+		pq.Array(ppq.Tags),
+		nullIfEmpty(ppq.Since),
+		nullIfEmpty(ppq.Until),
+		cursorCreatedAt,
+		cursorID,
+		ppq.Limit+1,
+		viewerId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := scanPostsForFeed(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	page := newPostsPage(posts, ppq.Limit)
+
+	// Pinned posts come first, on the first page only.
+	if ppq.Cursor == nil {
+		pinned, err := s.getPinned(ctx, userId, viewerId, ppq)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Posts = append(pinned, page.Posts...)
+	}
+
+	return page, nil
+}
+
+// Create saves post, resolving the mentions in post.Entities first. Mentions
+// of unknown usernames are dropped, unlike unknown MentionedUserIDs, which
+// fail with ErrUnknownMention.
 func (s *PostsStore) Create(ctx context.Context, post *Post) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		entities, err := resolveMentions(ctx, tx, post.Entities)
+		if err != nil {
+			return err
+		}
+		post.Entities = entities
+
+		if err := s.create(ctx, tx, post); err != nil {
+			return err
+		}
+
+		if err := createRevision(ctx, tx, post, post.UserID); err != nil {
+			return err
+		}
+
+		if err := s.createMentions(ctx, tx, post.ID, post.MentionedUserIDs); err != nil {
+			return err
+		}
+
+		if post.Poll != nil {
+			if err := createPoll(ctx, tx, post.ID, post.Poll); err != nil {
+				return err
+			}
+		}
+
+		return s.syncContentMentions(ctx, tx, post.ID, post.Entities.mentionedUserIDs())
+	})
+	if err != nil {
+		return err
+	}
+
+	// Rendered once committed, so a rolled back version is never cached.
+	post.renderContent()
+
+	return nil
+}
+
+func (s *PostsStore) create(ctx context.Context, tx *sql.Tx, post *Post) error {
 	query := `
-		INSERT INTO posts (content, title, user_id, tags) 
-		VALUES ($1, $2, $3, $4) 
-		RETURNING id, created_at, updated_at
+		INSERT INTO posts (content, title, user_id, tags, quoted_post_id, visibility, status, publish_at, entities,
+			reply_to_post_id, thread_id, link_url, content_warning, sensitive, comment_policy, comments_locked) 
+		VALUES ($1, $2, $3, ` + canonicalTags("$4") + `, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) 
+		RETURNING id, created_at, updated_at, version, tags
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	err := s.db.QueryRowContext(
+	if post.Visibility == "" {
+		post.Visibility = VisibilityPublic
+	}
+
+	if post.Status == "" {
+		post.Status = StatusPublished
+	}
+
+	if post.CommentPolicy == "" {
+		post.CommentPolicy = CommentPolicyEveryone
+	}
+
+	entities, err := entitiesJSON(post.Entities)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(
 		ctx,
 		query,
 		post.Content,
 		post.Title,
 		post.UserID,
 		pq.Array(post.Tags),
+		post.QuotedPostID,
+		post.Visibility,
+		post.Status,
+		post.PublishAt,
+		entities,
+		post.ReplyToPostID,
+		post.ThreadID,
+		post.LinkURL,
+		post.ContentWarning,
+		post.Sensitive,
+		post.CommentPolicy,
+		post.CommentsLocked,
 	).Scan(
 		&post.ID,
 		&post.CreatedAt,
 		&post.UpdatedAt,
+		&post.Version,
+		pq.Array(&post.Tags),
 	)
 
 	if err != nil {
@@ -121,12 +554,84 @@ func (s *PostsStore) Create(ctx context.Context, post *Post) error {
 	return nil
 }
 
+func (s *PostsStore) createMentions(ctx context.Context, tx *sql.Tx, postId int64, userIds []int64) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO mentions (post_id, user_id, explicit)
+		SELECT $1, unnest($2::bigint[]), true
+		ON CONFLICT (post_id, user_id) DO UPDATE SET explicit = true
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, postId, pq.Array(userIds))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return ErrUnknownMention
+		}
+		return err
+	}
+
+	return nil
+}
+
+// syncContentMentions makes userIds the users mentioned in the content of
+// postId, leaving the users mentioned through MentionedUserIDs alone.
+func (s *PostsStore) syncContentMentions(ctx context.Context, tx *sql.Tx, postId int64, userIds []int64) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	query := `DELETE FROM mentions WHERE post_id = $1 AND NOT explicit AND NOT user_id = ANY($2)`
+	if _, err := tx.ExecContext(ctx, query, postId, pq.Array(userIds)); err != nil {
+		return err
+	}
+
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	query = `
+		INSERT INTO mentions (post_id, user_id, explicit)
+		SELECT $1, unnest($2::bigint[]), false
+		ON CONFLICT (post_id, user_id) DO NOTHING
+	`
+	_, err := tx.ExecContext(ctx, query, postId, pq.Array(userIds))
+
+	return err
+}
+
+// IsVisibleTo reports whether viewerId may see postId, following the same
+// rules as visibleTo.
+func (s *PostsStore) IsVisibleTo(ctx context.Context, postId, viewerId int64) (bool, error) {
+	query := `SELECT EXISTS (
+		SELECT 1 FROM posts p WHERE p.id = $1 AND ` + visibleTo("p", "$2") + `
+	)`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var visible bool
+
+	err := s.db.QueryRowContext(ctx, query, postId, viewerId).Scan(&visible)
+
+	return visible, err
+}
+
 func (s *PostsStore) GetById(ctx context.Context, id int64) (*Post, error) {
-	query := `SELECT id, title, user_id, content, created_at, tags, updated_at, version
-		FROM posts 
-		WHERE id = $1
+	query := `SELECT p.id, p.title, p.user_id, p.content, p.created_at, p.tags, p.updated_at, p.version,
+		p.reaction_counts, p.quoted_post_id, p.visibility, p.status, p.publish_at, p.entities,
+		p.reply_to_post_id, p.thread_id, p.link_url, ` + linkPreviewJSON("p") + `,
+		p.content_warning, p.sensitive, p.content_warning_forced, p.comment_policy, p.comments_locked, u.username
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1 AND p.deleted_at IS NULL
 	`
 	post := Post{}
+	var linkPreview []byte
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
@@ -140,6 +645,22 @@ func (s *PostsStore) GetById(ctx context.Context, id int64) (*Post, error) {
 		pq.Array(&post.Tags),
 		&post.UpdatedAt,
 		&post.Version,
+		&post.ReactionCounts,
+		&post.QuotedPostID,
+		&post.Visibility,
+		&post.Status,
+		&post.PublishAt,
+		&post.Entities,
+		&post.ReplyToPostID,
+		&post.ThreadID,
+		&post.LinkURL,
+		&linkPreview,
+		&post.ContentWarning,
+		&post.Sensitive,
+		&post.ContentWarningForced,
+		&post.CommentPolicy,
+		&post.CommentsLocked,
+		&post.User.Username,
 	)
 
 	if err != nil {
@@ -151,54 +672,125 @@ func (s *PostsStore) GetById(ctx context.Context, id int64) (*Post, error) {
 		}
 	}
 
+	post.User.ID = post.UserID
+	post.renderContent()
+
+	if linkPreview != nil {
+		if err := json.Unmarshal(linkPreview, &post.LinkPreview); err != nil {
+			return nil, err
+		}
+	}
+
 	return &post, nil
 }
 
-func (s *PostsStore) DeleteById(ctx context.Context, id int64) error {
-	query := `DELETE FROM posts	
-		WHERE id = $1
-	`
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
-	defer cancel()
+// DeleteById moves a post that is still at version to the trash on behalf
+// of deletedBy. ErrEditConflict means it has been changed or deleted in the
+// meantime.
+func (s *PostsStore) DeleteById(ctx context.Context, id int64, version int, deletedBy int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `UPDATE posts
+			SET deleted_at = NOW(), deleted_by = $3
+			WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+		`
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
 
-	res, err := s.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return err
-	}
+		res, err := tx.ExecContext(ctx, query, id, version, deletedBy)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrEditConflict
+		}
+
+		return unpinHidden(ctx, tx, id)
+	})
+}
+
+// UpdateById saves post if it is still at post.Version and records the new
+// version as a revision made by editorId. ErrEditConflict means the post has
+// been changed or deleted in the meantime.
+func (s *PostsStore) UpdateById(ctx context.Context, post *Post, editorId int64) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		entities, err := resolveMentions(ctx, tx, post.Entities)
+		if err != nil {
+			return err
+		}
+		post.Entities = entities
+
+		if err := s.update(ctx, tx, post); err != nil {
+			return err
+		}
+
+		if err := createRevision(ctx, tx, post, editorId); err != nil {
+			return err
+		}
+
+		if err := unpinHidden(ctx, tx, post.ID); err != nil {
+			return err
+		}
 
-	rows, err := res.RowsAffected()
+		return s.syncContentMentions(ctx, tx, post.ID, post.Entities.mentionedUserIDs())
+	})
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return ErrNotFound
-	}
+	post.renderContent()
 
 	return nil
 }
 
-func (s *PostsStore) UpdateById(ctx context.Context, post *Post) error {
+func (s *PostsStore) update(ctx context.Context, tx *sql.Tx, post *Post) error {
+	// A post published by hand takes the time of publication as its
+	// creation time, so it lands at the top of feeds like a new post.
 	query := `UPDATE posts
-		SET title = $1, content = $2, version = version + 1
-		WHERE id = $3 AND version = $4 
-		RETURNING version
+		SET title = $1, content = $2, visibility = $5,
+			created_at = CASE WHEN status <> 'published' AND $6 = 'published' THEN NOW() ELSE created_at END,
+			status = $6, publish_at = $7, tags = ` + canonicalTags("$8") + `, entities = $9,
+			link_url = $10, content_warning = $11, sensitive = $12,
+			comment_policy = $13, comments_locked = $14,
+			version = version + 1, updated_at = NOW()
+		WHERE id = $3 AND version = $4 AND deleted_at IS NULL
+		RETURNING version, created_at, updated_at, tags
 	`
+	entities, err := entitiesJSON(post.Entities)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	err := s.db.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		query,
 		post.Title,
 		post.Content,
 		post.ID,
 		post.Version,
-	).Scan(&post.Version)
+		post.Visibility,
+		post.Status,
+		post.PublishAt,
+		pq.Array(post.Tags),
+		entities,
+		post.LinkURL,
+		post.ContentWarning,
+		post.Sensitive,
+		post.CommentPolicy,
+		post.CommentsLocked,
+	).Scan(&post.Version, &post.CreatedAt, &post.UpdatedAt, pq.Array(&post.Tags))
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return ErrNotFound
+			return ErrEditConflict
 		default:
 			return err
 		}
@@ -206,3 +798,249 @@ func (s *PostsStore) UpdateById(ctx context.Context, post *Post) error {
 
 	return nil
 }
+
+// CanComment reports whether userId may comment on postId, as allowed by
+// its comment policy. Authors may always comment on their posts, unless
+// comments are off or locked.
+func (s *PostsStore) CanComment(ctx context.Context, postId, userId int64) (bool, error) {
+	query := `SELECT ` + canComment("p", "$2") + ` FROM posts p WHERE p.id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var allowed bool
+
+	err := s.db.QueryRowContext(ctx, query, postId, userId).Scan(&allowed)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, ErrNotFound
+		default:
+			return false, err
+		}
+	}
+
+	return allowed, nil
+}
+
+// GetDrafts returns the draft and scheduled posts of userId, newest first.
+func (s *PostsStore) GetDrafts(ctx context.Context, userId int64, ppq PaginatedPostsQuery) (*PostsPage, error) {
+	query := `
+		SELECT ` + feedSelect{viewer: "$1", sortKey: "p.created_at, p.id"}.columns() + `
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE
+			p.user_id = $1 AND
+			p.deleted_at IS NULL AND
+			p.status IN ('draft', 'scheduled') AND
+			($2::timestamptz IS NULL OR (p.created_at, p.id) < ($2, $3))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $4
+	`
+
+	var cursorCreatedAt any
+	var cursorID int64
+	if ppq.Cursor != nil {
+		cursorCreatedAt = ppq.Cursor.CreatedAt
+		cursorID = ppq.Cursor.ID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userId, cursorCreatedAt, cursorID, ppq.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := scanPostsForFeed(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPostsPage(posts, ppq.Limit), nil
+}
+
+// GetByTag returns the posts tagged with tag, or with an alias of it,
+// newest first, as seen by viewerId, which is zero for anonymous viewers.
+func (s *PostsStore) GetByTag(ctx context.Context, tag string, viewerId int64, ppq PaginatedPostsQuery) (*PostsPage, error) {
+	query := `
+		SELECT ` + feedSelect{viewer: "$1", sortKey: "p.created_at, p.id"}.columns() + `
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE
+			p.tags @> ` + canonicalTags("ARRAY[$2::varchar]") + ` AND
+			` + visibleTo("p", "$1") + ` AND
+			($3::timestamptz IS NULL OR p.created_at >= $3) AND
+			($4::timestamptz IS NULL OR p.created_at <= $4) AND
+			($5::timestamptz IS NULL OR (p.created_at, p.id) < ($5, $6))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $7
+	`
+
+	var cursorCreatedAt any
+	var cursorID int64
+	if ppq.Cursor != nil {
+		cursorCreatedAt = ppq.Cursor.CreatedAt
+		cursorID = ppq.Cursor.ID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		viewerId,
+		tag,
+		nullIfEmpty(ppq.Since),
+		nullIfEmpty(ppq.Until),
+		cursorCreatedAt,
+		cursorID,
+		ppq.Limit+1,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := scanPostsForFeed(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPostsPage(posts, ppq.Limit), nil
+}
+
+// GetByIds returns the posts with the given ids that viewerId may see, in
+// the order of ids.
+func (s *PostsStore) GetByIds(ctx context.Context, viewerId int64, ids []int64) ([]*PostForFeed, error) {
+	query := `
+		SELECT ` + feedSelect{viewer: "$1", sortKey: "p.created_at, p.id"}.columns() + `
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS ids(id, n)
+		JOIN posts p ON p.id = ids.id
+		JOIN users u ON p.user_id = u.id
+		WHERE ` + visibleTo("p", "$1") + `
+		ORDER BY ids.n
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, viewerId, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := scanPostsForFeed(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if posts == nil {
+		posts = []*PostForFeed{}
+	}
+
+	return posts, nil
+}
+
+// GetMentioning returns the posts that mention userId, newest first.
+func (s *PostsStore) GetMentioning(ctx context.Context, userId int64, ppq PaginatedPostsQuery) (*PostsPage, error) {
+	query := `
+		SELECT ` + feedSelect{viewer: "$1", sortKey: "p.created_at, p.id"}.columns() + `
+		FROM mentions m
+		JOIN posts p ON p.id = m.post_id
+		JOIN users u ON p.user_id = u.id
+		WHERE
+			m.user_id = $1 AND
+			` + visibleTo("p", "$1") + ` AND
+			($2::timestamptz IS NULL OR (p.created_at, p.id) < ($2, $3))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $4
+	`
+
+	var cursorCreatedAt any
+	var cursorID int64
+	if ppq.Cursor != nil {
+		cursorCreatedAt = ppq.Cursor.CreatedAt
+		cursorID = ppq.Cursor.ID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userId, cursorCreatedAt, cursorID, ppq.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := scanPostsForFeed(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPostsPage(posts, ppq.Limit), nil
+}
+
+// PublishDue publishes up to limit scheduled posts whose time has come and
+// returns their ids. Publishing bumps the version of each post and records
+// it as a revision made by its author. Rows locked by a concurrent call are
+// skipped, so API replicas running it at the same time never publish a post
+// twice.
+func (s *PostsStore) PublishDue(ctx context.Context, limit int) ([]int64, error) {
+	query := `
+		UPDATE posts
+		SET status = 'published', created_at = publish_at, publish_at = NULL,
+			version = version + 1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM posts
+			WHERE status = 'scheduled' AND publish_at <= NOW() AND deleted_at IS NULL
+			ORDER BY publish_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, version, title, content
+	`
+
+	var ids []int64
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		rows, err := tx.QueryContext(ctx, query, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var posts []*Post
+
+		for rows.Next() {
+			var post Post
+			if err := rows.Scan(&post.ID, &post.UserID, &post.Version, &post.Title, &post.Content); err != nil {
+				return err
+			}
+
+			posts = append(posts, &post)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		rows.Close()
+
+		for _, post := range posts {
+			if err := createRevision(ctx, tx, post, post.UserID); err != nil {
+				return err
+			}
+
+			ids = append(ids, post.ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
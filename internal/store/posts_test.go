@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPublishDue(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	stranger := createTestUser(t, db, "stranger", "")
+
+	past := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	due := createTestPost(t, s, &Post{UserID: author.ID, Status: StatusScheduled, PublishAt: &past})
+	later := createTestPost(t, s, &Post{UserID: author.ID, Status: StatusScheduled, PublishAt: &future})
+	draft := createTestPost(t, s, &Post{UserID: author.ID, Status: StatusDraft})
+
+	t.Run("should hide posts until they are published", func(t *testing.T) {
+		for _, id := range []int64{due.ID, later.ID, draft.ID} {
+			visible, err := s.Posts.IsVisibleTo(ctx, id, stranger.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if visible {
+				t.Errorf("expected post %d to be hidden", id)
+			}
+		}
+	})
+
+	t.Run("should skip posts locked by another publisher", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.ExecContext(ctx, `SELECT id FROM posts WHERE id = $1 FOR UPDATE`, due.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		ids, err := s.Posts.PublishDue(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(ids) != 0 {
+			t.Errorf("expected no posts to be published; got %v", ids)
+		}
+	})
+
+	t.Run("should publish due posts only", func(t *testing.T) {
+		ids, err := s.Posts.PublishDue(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(ids, []int64{due.ID}) {
+			t.Fatalf("expected to publish %d; got %v", due.ID, ids)
+		}
+
+		visible, err := s.Posts.IsVisibleTo(ctx, due.ID, stranger.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !visible {
+			t.Error("expected the published post to be visible")
+		}
+
+		ids, err = s.Posts.PublishDue(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != 0 {
+			t.Errorf("expected nothing left to publish; got %v", ids)
+		}
+	})
+
+	t.Run("should record the publication as a revision", func(t *testing.T) {
+		post, err := s.Posts.GetById(ctx, due.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if post.Version != due.Version+1 {
+			t.Errorf("expected version %d; got %d", due.Version+1, post.Version)
+		}
+		if post.Status != StatusPublished || post.PublishAt != nil {
+			t.Errorf("expected a published post; got status %s, publish_at %v", post.Status, post.PublishAt)
+		}
+
+		revision, err := s.Revisions.GetByVersion(ctx, due.ID, post.Version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if revision.Editor == nil || revision.Editor.ID != author.ID || revision.ModeratorEdit {
+			t.Errorf("expected a revision by the author; got %+v", revision)
+		}
+	})
+}
+
+func TestGetByTag(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	follower := createTestUser(t, db, "follower", "")
+
+	follow(t, s, follower.ID, author.ID)
+
+	public := createTestPost(t, s, &Post{UserID: author.ID, Tags: []string{"go"}})
+	followersOnly := createTestPost(t, s, &Post{UserID: author.ID, Tags: []string{"go"}, Visibility: VisibilityFollowers})
+	aliased := createTestPost(t, s, &Post{UserID: author.ID, Tags: []string{"golang"}})
+	createTestPost(t, s, &Post{UserID: author.ID, Tags: []string{"rust"}})
+
+	if err := s.Tags.CreateAlias(ctx, &TagAlias{Alias: "golang", Tag: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(t *testing.T, tag string, viewerId int64, ppq PaginatedPostsQuery) *PostsPage {
+		t.Helper()
+
+		page, err := s.Posts.GetByTag(ctx, tag, viewerId, ppq)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return page
+	}
+
+	t.Run("should list the posts of the tag and its aliases", func(t *testing.T) {
+		expected := []int64{aliased.ID, followersOnly.ID, public.ID}
+
+		for _, tag := range []string{"go", "golang"} {
+			if got := postIDs(list(t, tag, follower.ID, PaginatedPostsQuery{Limit: 10}).Posts); !slices.Equal(got, expected) {
+				t.Errorf("%s: expected %v; got %v", tag, expected, got)
+			}
+		}
+	})
+
+	t.Run("should only list public posts to anonymous viewers", func(t *testing.T) {
+		expected := []int64{aliased.ID, public.ID}
+
+		if got := postIDs(list(t, "go", 0, PaginatedPostsQuery{Limit: 10}).Posts); !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+	})
+
+	t.Run("should page with cursors", func(t *testing.T) {
+		var got []int64
+
+		ppq := PaginatedPostsQuery{Limit: 2}
+		for {
+			page := list(t, "go", follower.ID, ppq)
+			got = append(got, postIDs(page.Posts)...)
+
+			if page.NextCursor == "" {
+				break
+			}
+
+			cursor, err := DecodeCursor(page.NextCursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ppq.Cursor = cursor
+		}
+
+		if expected := []int64{aliased.ID, followersOnly.ID, public.ID}; !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+	})
+}
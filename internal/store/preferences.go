@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// How a user wants posts with a content warning or flagged sensitive to be
+// shown to them.
+const (
+	DisplayShow = "show"
+	DisplayBlur = "blur"
+	DisplayHide = "hide"
+)
+
+type Preferences struct {
+	// SensitiveContent is one of DisplayShow, DisplayBlur or DisplayHide.
+	SensitiveContent string `json:"sensitive_content"`
+}
+
+// contentDisplay returns an SQL expression for how the post aliased as post
+// is to be shown to viewer, following the preferences of viewer.
+func contentDisplay(post, viewer string) string {
+	return fmt.Sprintf(`CASE
+		WHEN (%[1]s.content_warning = '' AND NOT %[1]s.sensitive) OR %[1]s.user_id = %[2]s THEN 'show'
+		ELSE COALESCE((
+			SELECT up.sensitive_content FROM user_preferences up WHERE up.user_id = %[2]s
+		), 'blur')
+	END`, post, viewer)
+}
+
+type PreferencesStore struct {
+	db *sql.DB
+}
+
+func NewPreferencesStore(db *sql.DB) *PreferencesStore {
+	return &PreferencesStore{db: db}
+}
+
+// Get returns the preferences of userId, which are the defaults until they
+// are first updated.
+func (s *PreferencesStore) Get(ctx context.Context, userId int64) (*Preferences, error) {
+	query := `
+		SELECT COALESCE((SELECT sensitive_content FROM user_preferences WHERE user_id = $1), 'blur')
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var prefs Preferences
+
+	if err := s.db.QueryRowContext(ctx, query, userId).Scan(&prefs.SensitiveContent); err != nil {
+		return nil, err
+	}
+
+	return &prefs, nil
+}
+
+func (s *PreferencesStore) Update(ctx context.Context, userId int64, prefs *Preferences) error {
+	query := `
+		INSERT INTO user_preferences (user_id, sensitive_content)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			sensitive_content = EXCLUDED.sensitive_content, updated_at = NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userId, prefs.SensitiveContent)
+
+	return err
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContentDisplay(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	reader := createTestUser(t, db, "reader", "")
+
+	plain := createTestPost(t, s, &Post{UserID: author.ID})
+	warned := createTestPost(t, s, &Post{UserID: author.ID, ContentWarning: "spoilers"})
+	sensitive := createTestPost(t, s, &Post{UserID: author.ID, Sensitive: true})
+
+	display := func(t *testing.T, viewerId int64) map[int64]string {
+		t.Helper()
+
+		posts, err := s.Posts.GetByIds(ctx, viewerId, []int64{plain.ID, warned.ID, sensitive.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		display := make(map[int64]string)
+		for _, post := range posts {
+			display[post.ID] = post.ContentDisplay
+		}
+
+		return display
+	}
+
+	check := func(t *testing.T, viewerId int64, expected string) {
+		t.Helper()
+
+		got := display(t, viewerId)
+
+		if got[plain.ID] != DisplayShow {
+			t.Errorf("plain post: expected %s; got %s", DisplayShow, got[plain.ID])
+		}
+		for _, post := range []*Post{warned, sensitive} {
+			if got[post.ID] != expected {
+				t.Errorf("post %d: expected %s; got %s", post.ID, expected, got[post.ID])
+			}
+		}
+	}
+
+	t.Run("should blur by default", func(t *testing.T) {
+		prefs, err := s.Preferences.Get(ctx, reader.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prefs.SensitiveContent != DisplayBlur {
+			t.Errorf("expected the default to be %s; got %s", DisplayBlur, prefs.SensitiveContent)
+		}
+
+		check(t, reader.ID, DisplayBlur)
+	})
+
+	t.Run("should follow the preferences of the viewer", func(t *testing.T) {
+		for _, pref := range []string{DisplayHide, DisplayShow, DisplayBlur} {
+			if err := s.Preferences.Update(ctx, reader.ID, &Preferences{SensitiveContent: pref}); err != nil {
+				t.Fatal(err)
+			}
+
+			check(t, reader.ID, pref)
+		}
+	})
+
+	t.Run("should show authors their own posts", func(t *testing.T) {
+		if err := s.Preferences.Update(ctx, author.ID, &Preferences{SensitiveContent: DisplayHide}); err != nil {
+			t.Fatal(err)
+		}
+
+		check(t, author.ID, DisplayShow)
+	})
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ReactionCounts maps a reaction kind to the number of users who reacted
+// with it. It is stored denormalized on posts.reaction_counts.
+type ReactionCounts map[string]int
+
+func (rc *ReactionCounts) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*rc = ReactionCounts{}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into ReactionCounts", src)
+	}
+
+	counts := ReactionCounts{}
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return err
+	}
+
+	*rc = counts
+	return nil
+}
+
+type ReactionsStore struct {
+	db *sql.DB
+}
+
+func NewReactionsStore(db *sql.DB) *ReactionsStore {
+	return &ReactionsStore{db: db}
+}
+
+// Set records kind as the reaction of userId to postId, replacing any
+// previous reaction of that user. Setting the same kind twice is a no-op.
+func (s *ReactionsStore) Set(ctx context.Context, postId, userId int64, kind string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		// Locking the post serializes reactions to it, so the previous kind
+		// read below cannot change before the counters are adjusted.
+		if err := lockReactable(ctx, tx, "posts", postId); err != nil {
+			return err
+		}
+
+		prev, err := s.get(ctx, tx, postId, userId)
+		if err != nil {
+			return err
+		}
+
+		if prev == kind {
+			return nil
+		}
+
+		query := `
+			INSERT INTO post_reactions (post_id, user_id, kind)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (post_id, user_id) DO UPDATE
+			SET kind = EXCLUDED.kind, created_at = NOW()
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if _, err := tx.ExecContext(ctx, query, postId, userId, kind); err != nil {
+			return err
+		}
+
+		if prev != "" {
+			if err := adjustReactionCount(ctx, tx, "posts", "", postId, prev, -1); err != nil {
+				return err
+			}
+		}
+
+		return adjustReactionCount(ctx, tx, "posts", "", postId, kind, 1)
+	})
+}
+
+// Delete removes the reaction of userId to postId. Deleting a reaction that
+// does not exist is a no-op.
+func (s *ReactionsStore) Delete(ctx context.Context, postId, userId int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		// The post is locked before the reaction, in the same order as Set,
+		// so the two can't deadlock.
+		if err := lockReactable(ctx, tx, "posts", postId); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		query := `
+			DELETE FROM post_reactions
+			WHERE post_id = $1 AND user_id = $2
+			RETURNING kind
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var kind string
+
+		err := tx.QueryRowContext(ctx, query, postId, userId).Scan(&kind)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return nil
+			default:
+				return err
+			}
+		}
+
+		return adjustReactionCount(ctx, tx, "posts", "", postId, kind, -1)
+	})
+}
+
+// GetByUser returns the reaction kind userId left on postId, or an empty
+// string if there is none.
+func (s *ReactionsStore) GetByUser(ctx context.Context, postId, userId int64) (string, error) {
+	return s.get(ctx, s.db, postId, userId)
+}
+
+func (s *ReactionsStore) get(ctx context.Context, q rowQuerier, postId, userId int64) (string, error) {
+	query := `SELECT kind FROM post_reactions WHERE post_id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var kind string
+
+	err := q.QueryRowContext(ctx, query, postId, userId).Scan(&kind)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return "", nil
+		default:
+			return "", err
+		}
+	}
+
+	return kind, nil
+}
+
+// adjustReactionCount adds delta to the count of kind in the
+// reaction_counts of row id of table, dropping kinds no longer used. When
+// total names a column, it is kept as the sum of the counts. table and total
+// are never user input.
+func adjustReactionCount(ctx context.Context, tx *sql.Tx, table, total string, id int64, kind string, delta int) error {
+	set := `
+		reaction_counts = CASE
+			WHEN COALESCE((reaction_counts->>$2::text)::int, 0) + $3::int <= 0
+				THEN reaction_counts - $2::text
+			ELSE jsonb_set(
+				reaction_counts,
+				ARRAY[$2::text],
+				to_jsonb(COALESCE((reaction_counts->>$2::text)::int, 0) + $3::int)
+			)
+		END
+	`
+	if total != "" {
+		set += fmt.Sprintf(`, %[1]s = GREATEST(%[1]s + $3::int, 0)`, total)
+	}
+
+	query := `UPDATE ` + table + ` SET ` + set + ` WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, id, kind, delta)
+
+	return err
+}
+
+// lockReactable locks row id of table, so reactions to it are serialized.
+// ErrNotFound means it doesn't exist or is deleted. table is never user
+// input.
+func lockReactable(ctx context.Context, tx *sql.Tx, table string, id int64) error {
+	query := `SELECT id FROM ` + table + ` WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := tx.QueryRowContext(ctx, query, id).Scan(&id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"testing"
+)
+
+func TestReactions(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	alice := createTestUser(t, db, "alice", "")
+	bob := createTestUser(t, db, "bob", "")
+
+	post := createTestPost(t, s, &Post{UserID: author.ID})
+	comment := createTestComment(t, s, &Comment{PostId: post.ID, UserId: author.ID})
+
+	counts := func(t *testing.T, table string, id int64) ReactionCounts {
+		t.Helper()
+
+		var data []byte
+		if err := db.QueryRow(`SELECT reaction_counts FROM `+table+` WHERE id = $1`, id).Scan(&data); err != nil {
+			t.Fatal(err)
+		}
+
+		counts := ReactionCounts{}
+		if err := json.Unmarshal(data, &counts); err != nil {
+			t.Fatal(err)
+		}
+
+		return counts
+	}
+
+	stores := []struct {
+		table string
+		id    int64
+		set   func(context.Context, int64, int64, string) error
+		del   func(context.Context, int64, int64) error
+	}{
+		{"posts", post.ID, s.Reactions.Set, s.Reactions.Delete},
+		{"comments", comment.ID, s.CommentReactions.Set, s.CommentReactions.Delete},
+	}
+
+	for _, st := range stores {
+		t.Run(st.table, func(t *testing.T) {
+			steps := []struct {
+				name     string
+				run      func() error
+				expected ReactionCounts
+			}{
+				{"should count reactions", func() error { return st.set(ctx, st.id, alice.ID, "like") }, ReactionCounts{"like": 1}},
+				{"should count each user", func() error { return st.set(ctx, st.id, bob.ID, "like") }, ReactionCounts{"like": 2}},
+				{"should ignore repeats", func() error { return st.set(ctx, st.id, bob.ID, "like") }, ReactionCounts{"like": 2}},
+				{"should move changed reactions", func() error { return st.set(ctx, st.id, alice.ID, "love") }, ReactionCounts{"like": 1, "love": 1}},
+				{"should drop unused kinds", func() error { return st.del(ctx, st.id, alice.ID) }, ReactionCounts{"like": 1}},
+				{"should ignore missing reactions", func() error { return st.del(ctx, st.id, alice.ID) }, ReactionCounts{"like": 1}},
+			}
+
+			for _, step := range steps {
+				if err := step.run(); err != nil {
+					t.Fatalf("%s: %v", step.name, err)
+				}
+
+				if got := counts(t, st.table, st.id); !maps.Equal(got, step.expected) {
+					t.Errorf("%s: expected %v; got %v", step.name, step.expected, got)
+				}
+			}
+		})
+	}
+
+	t.Run("should keep the comment total in step", func(t *testing.T) {
+		n := countTest(t, db, `SELECT reactions_count FROM comments WHERE id = $1`, comment.ID)
+		if n != 1 {
+			t.Errorf("expected a total of 1; got %d", n)
+		}
+	})
+
+	t.Run("should not react to deleted posts", func(t *testing.T) {
+		deleted := createTestPost(t, s, &Post{UserID: author.ID})
+		if err := s.Posts.DeleteById(ctx, deleted.ID, deleted.Version, author.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Reactions.Set(ctx, deleted.ID, alice.ID, "like"); err != ErrNotFound {
+			t.Errorf("expected ErrNotFound; got %v", err)
+		}
+	})
+}
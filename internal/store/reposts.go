@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type RepostsStore struct {
+	db *sql.DB
+}
+
+func NewRepostsStore(db *sql.DB) *RepostsStore {
+	return &RepostsStore{db: db}
+}
+
+// Create reposts postId on behalf of userId. Reposting twice is a no-op.
+func (s *RepostsStore) Create(ctx context.Context, userId, postId int64) error {
+	query := `
+		INSERT INTO reposts (user_id, post_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, post_id) DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userId, postId)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Delete undoes a repost. Deleting a missing repost is a no-op.
+func (s *RepostsStore) Delete(ctx context.Context, userId, postId int64) error {
+	query := `DELETE FROM reposts WHERE user_id = $1 AND post_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userId, postId)
+
+	return err
+}
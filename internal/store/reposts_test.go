@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReposts(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	reader := createTestUser(t, db, "reader", "")
+	alice := createTestUser(t, db, "alice", "")
+	bob := createTestUser(t, db, "bob", "")
+	author := createTestUser(t, db, "author", "")
+
+	follow(t, s, reader.ID, alice.ID)
+	follow(t, s, reader.ID, bob.ID)
+
+	post := createTestPost(t, s, &Post{UserID: author.ID})
+
+	feed := func(t *testing.T) []*PostForFeed {
+		t.Helper()
+
+		page, err := s.Posts.GetUserFeed(ctx, reader.ID, PaginatedFeedQuery{Limit: 10, Sort: "desc", Tags: []string{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return page.Posts
+	}
+
+	repostedBy := func(t *testing.T, expected *User) {
+		t.Helper()
+
+		posts := feed(t)
+		if len(posts) != 1 || posts[0].ID != post.ID {
+			t.Fatalf("expected post %d once; got %v", post.ID, postIDs(posts))
+		}
+
+		if got := posts[0].RepostedBy; got == nil || got.ID != expected.ID || got.Username != expected.Username {
+			t.Errorf("expected to be reposted by %s; got %+v", expected.Username, got)
+		}
+	}
+
+	if err := s.Reposts.Create(ctx, alice.ID, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	execTest(t, db, `UPDATE reposts SET created_at = NOW() - interval '1 hour' WHERE user_id = $1`, alice.ID)
+
+	t.Run("should bring reposts of followed users into the feed", func(t *testing.T) {
+		repostedBy(t, alice)
+	})
+
+	t.Run("should collapse reposts into the newest one", func(t *testing.T) {
+		if err := s.Reposts.Create(ctx, bob.ID, post.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		repostedBy(t, bob)
+	})
+
+	t.Run("should ignore repeated reposts", func(t *testing.T) {
+		if err := s.Reposts.Create(ctx, bob.ID, post.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if n := countTest(t, db, `SELECT COUNT(*) FROM reposts WHERE post_id = $1`, post.ID); n != 2 {
+			t.Errorf("expected 2 reposts; got %d", n)
+		}
+	})
+
+	t.Run("should fall back to the previous repost once undone", func(t *testing.T) {
+		if err := s.Reposts.Delete(ctx, bob.ID, post.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		repostedBy(t, alice)
+	})
+
+	t.Run("should not repost unknown posts", func(t *testing.T) {
+		if err := s.Reposts.Create(ctx, alice.ID, -1); err != ErrNotFound {
+			t.Errorf("expected ErrNotFound; got %v", err)
+		}
+	})
+}
+
+func TestQuotedPosts(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	reader := createTestUser(t, db, "reader", "")
+	quoter := createTestUser(t, db, "quoter", "")
+	author := createTestUser(t, db, "author", "")
+
+	follow(t, s, reader.ID, quoter.ID)
+
+	public := createTestPost(t, s, &Post{UserID: author.ID, Title: "public"})
+	followersOnly := createTestPost(t, s, &Post{UserID: author.ID, Visibility: VisibilityFollowers})
+	deleted := createTestPost(t, s, &Post{UserID: author.ID})
+
+	quotes := map[int64]*Post{}
+	for _, quoted := range []*Post{public, followersOnly, deleted} {
+		quotes[quoted.ID] = createTestPost(t, s, &Post{UserID: quoter.ID, QuotedPostID: &quoted.ID})
+	}
+
+	if err := s.Posts.DeleteById(ctx, deleted.ID, deleted.Version, author.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := s.Posts.GetUserFeed(ctx, reader.ID, PaginatedFeedQuery{Limit: 10, Sort: "desc", Tags: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byID := map[int64]*PostForFeed{}
+	for _, post := range page.Posts {
+		byID[post.ID] = post
+	}
+
+	t.Run("should embed visible quoted posts", func(t *testing.T) {
+		quote := byID[quotes[public.ID].ID]
+		if quote == nil {
+			t.Fatal("expected the quote in the feed")
+		}
+
+		if quote.QuotedPost == nil || quote.QuotedPost.ID != public.ID || quote.QuotedPost.Title != "public" {
+			t.Errorf("expected post %d to be embedded; got %+v", public.ID, quote.QuotedPost)
+		}
+		if quote.QuotedPost != nil && quote.QuotedPost.User.ID != author.ID {
+			t.Errorf("expected the quoted post by %d; got %d", author.ID, quote.QuotedPost.User.ID)
+		}
+	})
+
+	for name, quoted := range map[string]*Post{"hidden": followersOnly, "deleted": deleted} {
+		t.Run("should leave out "+name+" quoted posts", func(t *testing.T) {
+			quote := byID[quotes[quoted.ID].ID]
+			if quote == nil {
+				t.Fatal("expected the quote in the feed")
+			}
+
+			if quote.QuotedPostID == nil || *quote.QuotedPostID != quoted.ID {
+				t.Errorf("expected the quoted post id %d; got %v", quoted.ID, quote.QuotedPostID)
+			}
+			if quote.QuotedPost != nil {
+				t.Errorf("expected no embedded post; got %+v", quote.QuotedPost)
+			}
+		})
+	}
+}
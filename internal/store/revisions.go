@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Revision is an immutable snapshot of a post's title and content, written
+// whenever the post is created or updated.
+type Revision struct {
+	ID      int64  `json:"id"`
+	PostID  int64  `json:"post_id"`
+	Version int    `json:"version"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	// Editor is nil once the account of the editor is deleted.
+	Editor    *User  `json:"editor"`
+	CreatedAt string `json:"created_at"`
+	// ModeratorEdit is set when the revision was made by someone other than
+	// the author of the post.
+	ModeratorEdit bool `json:"moderator_edit"`
+}
+
+type RevisionsStore struct {
+	db *sql.DB
+}
+
+func NewRevisionsStore(db *sql.DB) *RevisionsStore {
+	return &RevisionsStore{db: db}
+}
+
+const revisionColumns = `
+	r.id, r.post_id, r.version, r.title, r.content, r.created_at,
+	e.id, e.username, r.editor_id IS DISTINCT FROM p.user_id AS moderator_edit
+`
+
+func scanRevision(row interface{ Scan(...any) error }, r *Revision) error {
+	var editorID sql.NullInt64
+	var editorUsername sql.NullString
+
+	err := row.Scan(
+		&r.ID,
+		&r.PostID,
+		&r.Version,
+		&r.Title,
+		&r.Content,
+		&r.CreatedAt,
+		&editorID,
+		&editorUsername,
+		&r.ModeratorEdit,
+	)
+	if err != nil {
+		return err
+	}
+
+	if editorID.Valid {
+		r.Editor = &User{ID: editorID.Int64, Username: editorUsername.String}
+	}
+
+	return nil
+}
+
+// GetByPostId returns the revisions of a post, newest first.
+func (s *RevisionsStore) GetByPostId(ctx context.Context, postId int64) ([]Revision, error) {
+	query := `
+		SELECT ` + revisionColumns + `
+		FROM post_revisions r
+		JOIN posts p ON p.id = r.post_id
+		LEFT JOIN users e ON e.id = r.editor_id
+		WHERE r.post_id = $1
+		ORDER BY r.version DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, postId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+
+	for rows.Next() {
+		r := Revision{}
+
+		if err := scanRevision(rows, &r); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, r)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (s *RevisionsStore) GetByVersion(ctx context.Context, postId int64, version int) (*Revision, error) {
+	query := `
+		SELECT ` + revisionColumns + `
+		FROM post_revisions r
+		JOIN posts p ON p.id = r.post_id
+		LEFT JOIN users e ON e.id = r.editor_id
+		WHERE r.post_id = $1 AND r.version = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	r := Revision{}
+
+	err := scanRevision(s.db.QueryRowContext(ctx, query, postId, version), &r)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &r, nil
+}
+
+// createRevision snapshots post as it is after a create or an update made
+// by editorId.
+func createRevision(ctx context.Context, tx *sql.Tx, post *Post, editorId int64) error {
+	query := `
+		INSERT INTO post_revisions (post_id, version, title, content, editor_id)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, post.ID, post.Version, post.Title, post.Content, editorId)
+
+	return err
+}
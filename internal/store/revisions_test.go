@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRevisionsKeepDeletedEditors(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	moderator := createTestUser(t, db, "moderator", "moderator")
+
+	post := createTestPost(t, s, &Post{UserID: author.ID})
+
+	post.Content = "moderated"
+	if err := s.Posts.UpdateById(ctx, post, moderator.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	execTest(t, db, `DELETE FROM users WHERE id = $1`, moderator.ID)
+
+	revisions, err := s.Revisions.GetByPostId(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions; got %d", len(revisions))
+	}
+
+	if edit := revisions[0]; edit.Editor != nil || !edit.ModeratorEdit {
+		t.Errorf("expected a moderator edit without an editor; got %+v", edit)
+	}
+
+	if original := revisions[1]; original.Editor == nil || original.Editor.ID != author.ID || original.ModeratorEdit {
+		t.Errorf("expected the original by the author; got %+v", original)
+	}
+}
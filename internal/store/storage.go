@@ -10,6 +10,7 @@ import (
 var (
 	ErrNotFound          = errors.New("record not found")
 	ErrConflict          = errors.New("record already exists")
+	ErrEditConflict      = errors.New("record was modified concurrently")
 	QueryTimeoutDuration = time.Second * 5
 )
 
@@ -25,33 +26,145 @@ type Storage struct {
 	Posts interface {
 		Create(context.Context, *Post) error
 		GetById(context.Context, int64) (*Post, error)
-		DeleteById(context.Context, int64) error
-		UpdateById(context.Context, *Post) error
-		GetUserFeed(context.Context, int64, PaginatedFeedQuery) ([]*PostForFeed, error)
+		DeleteById(context.Context, int64, int, int64) error
+		UpdateById(context.Context, *Post, int64) error
+		GetUserFeed(context.Context, int64, PaginatedFeedQuery) (*FeedPage, error)
+		GetByUser(context.Context, int64, int64, PaginatedPostsQuery) (*PostsPage, error)
+		IsVisibleTo(context.Context, int64, int64) (bool, error)
+		CanComment(context.Context, int64, int64) (bool, error)
+		GetDrafts(context.Context, int64, PaginatedPostsQuery) (*PostsPage, error)
+		GetMentioning(context.Context, int64, PaginatedPostsQuery) (*PostsPage, error)
+		GetByIds(context.Context, int64, []int64) ([]*PostForFeed, error)
+		GetByTag(context.Context, string, int64, PaginatedPostsQuery) (*PostsPage, error)
+		GetThread(context.Context, int64, int64, ThreadQuery) (*ThreadNode, error)
+		PublishDue(context.Context, int) ([]int64, error)
 	}
 	Comments interface {
-		GetByPostId(context.Context, int64) ([]Comment, error)
+		GetByPostId(context.Context, int64, int64, CommentsQuery) (*CommentsPage, error)
+		GetById(context.Context, int64) (*Comment, error)
 		Create(context.Context, *Comment) error
+		Update(context.Context, *Comment) error
+		Delete(context.Context, int64, int64) error
+		Highlight(context.Context, int64, int64) error
+		Unhighlight(context.Context, int64, int64) error
+		SetHidden(context.Context, int64, bool) error
+	}
+	CommentReactions interface {
+		Set(context.Context, int64, int64, string) error
+		Delete(context.Context, int64, int64) error
 	}
 	Followers interface {
 		Follow(context.Context, int64, int64) error
 		Unfollow(context.Context, int64, int64) error
 	}
+	Blocks interface {
+		Block(context.Context, int64, int64) error
+		Unblock(context.Context, int64, int64) error
+	}
 	Roles interface {
 		GetByName(context.Context, string) (*Role, error)
 	}
+	Reactions interface {
+		Set(context.Context, int64, int64, string) error
+		Delete(context.Context, int64, int64) error
+		GetByUser(context.Context, int64, int64) (string, error)
+	}
+	Bookmarks interface {
+		GetCollections(context.Context, int64) ([]BookmarkCollection, error)
+		CreateCollection(context.Context, *BookmarkCollection) error
+		DeleteCollection(context.Context, int64, int64) error
+		Add(context.Context, int64, int64, int64) error
+		Remove(context.Context, int64, int64, int64) error
+		GetPosts(context.Context, int64, int64, PaginatedPostsQuery) (*PostsPage, error)
+	}
+	Reposts interface {
+		Create(context.Context, int64, int64) error
+		Delete(context.Context, int64, int64) error
+	}
+	Revisions interface {
+		GetByPostId(context.Context, int64) ([]Revision, error)
+		GetByVersion(context.Context, int64, int) (*Revision, error)
+	}
+	Trash interface {
+		Get(context.Context, int64, time.Time, TrashQuery) (*Trash, error)
+		RestorePost(context.Context, int64, int64, time.Time, bool) error
+		RestoreComment(context.Context, int64, int64, time.Time, bool) error
+		Purge(context.Context, time.Time, int) (int64, error)
+	}
+	Tags interface {
+		GetAliases(context.Context) ([]TagAlias, error)
+		CreateAlias(context.Context, *TagAlias) error
+		DeleteAlias(context.Context, string) error
+		Follow(context.Context, int64, string) error
+		Unfollow(context.Context, int64, string) error
+		GetFollowed(context.Context, int64) ([]string, error)
+	}
+	Polls interface {
+		Get(context.Context, int64, int64) (*Poll, error)
+		Vote(context.Context, int64, int64, []int64) error
+	}
+	Trending interface {
+		ComputePosts(context.Context, int) ([]TrendingPost, error)
+		ComputeTags(context.Context, int) ([]TrendingTag, error)
+		GetPosts(context.Context, int) ([]TrendingPost, error)
+		GetTags(context.Context, int) ([]TrendingTag, error)
+		Refresh(context.Context) error
+	}
+	Pins interface {
+		Pin(context.Context, int64, int64) error
+		Unpin(context.Context, int64, int64) error
+		Reorder(context.Context, int64, []int64) error
+	}
+	Preferences interface {
+		Get(context.Context, int64) (*Preferences, error)
+		Update(context.Context, int64, *Preferences) error
+	}
+	Moderation interface {
+		SetContentWarning(context.Context, int64, int64, ContentWarning, string) (int, error)
+	}
+	Analytics interface {
+		AddViews(context.Context, []DailyViews) error
+		Get(context.Context, int64, time.Time, int) (*Analytics, error)
+	}
+	LinkPreviews interface {
+		GetPending(context.Context, time.Time, int) ([]string, error)
+		Save(context.Context, *LinkPreview) error
+		SaveFailed(context.Context, string) error
+	}
 }
 
 func NewStorage(db *sql.DB) Storage {
 	return Storage{
-		Posts:     NewPostsStore(db),
-		Users:     NewUsersStore(db),
-		Comments:  NewCommentsStore(db),
-		Followers: NewFollowerStore(db),
-		Roles:     NewRolesStore(db),
+		Posts:            NewPostsStore(db),
+		Users:            NewUsersStore(db),
+		Comments:         NewCommentsStore(db),
+		Followers:        NewFollowerStore(db),
+		Blocks:           NewBlocksStore(db),
+		Roles:            NewRolesStore(db),
+		Reactions:        NewReactionsStore(db),
+		CommentReactions: NewCommentReactionsStore(db),
+		Bookmarks:        NewBookmarksStore(db),
+		Reposts:          NewRepostsStore(db),
+		Revisions:        NewRevisionsStore(db),
+		Trash:            NewTrashStore(db),
+		Tags:             NewTagsStore(db),
+		Trending:         NewTrendingStore(db),
+		Polls:            NewPollsStore(db),
+		Pins:             NewPinsStore(db),
+
+		Preferences:  NewPreferencesStore(db),
+		Moderation:   NewModerationStore(db),
+		LinkPreviews: NewLinkPreviewsStore(db),
+		Analytics:    NewAnalyticsStore(db),
 	}
 }
 
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx, for reads that are
+// needed inside and outside of a transaction.
+type rowQuerier interface {
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}
+
 func withTx(db *sql.DB, ctx context.Context, fn func(*sql.Tx) error) error {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
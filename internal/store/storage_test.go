@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Tests that need Postgres run against the database at TEST_DB_ADDR, and are
+// skipped when it is not set. It must be a throwaway database: its public
+// schema is recreated from the migrations before the first of them, and
+// every table but roles is emptied before each of them.
+var (
+	testDBOnce sync.Once
+	testDB     *sql.DB
+	testDBErr  error
+)
+
+func newTestStorage(t *testing.T) (Storage, *sql.DB) {
+	t.Helper()
+
+	addr := os.Getenv("TEST_DB_ADDR")
+	if addr == "" {
+		t.Skip("TEST_DB_ADDR is not set")
+	}
+
+	testDBOnce.Do(func() {
+		testDB, testDBErr = openTestDB(addr)
+	})
+	if testDBErr != nil {
+		t.Fatal(testDBErr)
+	}
+
+	query := `
+		DO $$
+		DECLARE
+			tables text;
+		BEGIN
+			SELECT string_agg(quote_ident(tablename), ', ') INTO tables
+			FROM pg_tables
+			WHERE schemaname = 'public' AND tablename <> 'roles';
+
+			EXECUTE 'TRUNCATE ' || tables || ' RESTART IDENTITY CASCADE';
+		END $$
+	`
+	if _, err := testDB.Exec(query); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewStorage(testDB), testDB
+}
+
+func openTestDB(addr string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(`DROP SCHEMA public CASCADE; CREATE SCHEMA public`); err != nil {
+		return nil, err
+	}
+
+	migrations, err := filepath.Glob("../../cmd/migrate/migrations/*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(migrations)
+
+	for _, migration := range migrations {
+		data, err := os.ReadFile(migration)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := db.Exec(string(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+// createTestUser adds an active user with the given role, "user" when empty.
+func createTestUser(t *testing.T, db *sql.DB, username, role string) *User {
+	t.Helper()
+
+	if role == "" {
+		role = "user"
+	}
+
+	query := `
+		INSERT INTO users (username, email, password, role_id, is_active)
+		VALUES ($1, $1 || '@example.com', '', (SELECT id FROM roles WHERE name = $2), true)
+		RETURNING id
+	`
+
+	user := &User{Username: username, Email: username + "@example.com"}
+	if err := db.QueryRow(query, username, role).Scan(&user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+// createTestPost saves post, published and public unless set otherwise.
+func createTestPost(t *testing.T, s Storage, post *Post) *Post {
+	t.Helper()
+
+	if post.Title == "" {
+		post.Title = "title"
+	}
+	if post.Content == "" {
+		post.Content = "content"
+	}
+	if post.Tags == nil {
+		post.Tags = []string{}
+	}
+
+	if err := s.Posts.Create(context.Background(), post); err != nil {
+		t.Fatal(err)
+	}
+
+	return post
+}
+
+// createTestComment saves comment, with some content unless set.
+func createTestComment(t *testing.T, s Storage, comment *Comment) *Comment {
+	t.Helper()
+
+	if comment.Content == "" {
+		comment.Content = "comment"
+	}
+
+	if err := s.Comments.Create(context.Background(), comment); err != nil {
+		t.Fatal(err)
+	}
+
+	return comment
+}
+
+// follow makes followerId follow userId, the way followUserHandler does.
+func follow(t *testing.T, s Storage, followerId, userId int64) {
+	t.Helper()
+
+	if err := s.Followers.Follow(context.Background(), userId, followerId); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// postIDs returns the ids of posts, in order.
+func postIDs(posts []*PostForFeed) []int64 {
+	ids := make([]int64, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	return ids
+}
+
+// countTest returns the count selected by query, failing t on error.
+func countTest(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", strings.TrimSpace(query), err)
+	}
+
+	return n
+}
+
+// execTest runs query, failing t on error. It is for setting up states the
+// stores cannot reach, such as items old enough to be purged.
+func execTest(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", strings.TrimSpace(query), err)
+	}
+}
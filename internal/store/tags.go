@@ -0,0 +1,277 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+const (
+	MaxTagLength   = 32
+	MaxTagsPerPost = 10
+)
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+// NormalizeTag folds tag to its canonical form: without a leading '#' and in
+// lower case. Tags may only contain letters, digits and underscores.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+
+	if tag == "" {
+		return "", fmt.Errorf("%w: tags cannot be empty", ErrInvalidTag)
+	}
+
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidTag, tag, MaxTagLength)
+	}
+
+	for _, r := range tag {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return "", fmt.Errorf("%w: %q may only contain letters, digits and underscores", ErrInvalidTag, tag)
+		}
+	}
+
+	return tag, nil
+}
+
+// NormalizeTags normalizes every tag and drops duplicates, keeping the
+// order in which tags first appear. It never returns nil, as the posts
+// table doesn't allow NULL tags.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		t, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[t] {
+			continue
+		}
+
+		seen[t] = true
+		normalized = append(normalized, t)
+	}
+
+	return normalized, nil
+}
+
+// canonicalTags returns an SQL expression that maps every tag of the array
+// expr through tag_aliases and drops the duplicates that this creates.
+func canonicalTags(expr string) string {
+	return fmt.Sprintf(`ARRAY(
+		SELECT c.tag FROM (
+			SELECT COALESCE(a.tag, t.tag) AS tag, MIN(t.n) AS n
+			FROM unnest(%s::varchar[]) WITH ORDINALITY AS t(tag, n)
+			LEFT JOIN tag_aliases a ON a.alias = t.tag
+			GROUP BY 1
+		) c
+		ORDER BY c.n
+	)`, expr)
+}
+
+// TagAlias merges Alias into Tag: posts are tagged, and filtered, with Tag
+// whenever Alias is used.
+type TagAlias struct {
+	Alias     string `json:"alias"`
+	Tag       string `json:"tag"`
+	CreatedBy *int64 `json:"created_by"`
+	CreatedAt string `json:"created_at"`
+}
+
+type TagsStore struct {
+	db *sql.DB
+}
+
+func NewTagsStore(db *sql.DB) *TagsStore {
+	return &TagsStore{db: db}
+}
+
+func (s *TagsStore) GetAliases(ctx context.Context) ([]TagAlias, error) {
+	query := `SELECT alias, tag, created_by, created_at FROM tag_aliases ORDER BY tag, alias`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := []TagAlias{}
+	for rows.Next() {
+		var a TagAlias
+		if err := rows.Scan(&a.Alias, &a.Tag, &a.CreatedBy, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+
+	return aliases, rows.Err()
+}
+
+// CreateAlias merges alias.Alias into alias.Tag. Posts already tagged with
+// the alias are retagged, and aliases pointing at the alias are repointed,
+// so that aliases never chain. ErrConflict means the alias exists already
+// or the tag is itself an alias.
+func (s *TagsStore) CreateAlias(ctx context.Context, alias *TagAlias) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		// Tags have no rows of their own, so both are locked by name until
+		// the transaction ends. Concurrent aliases sharing a tag, such as
+		// a to b and b to a, then see each other and can't chain. Locks are
+		// taken in order so the two can't deadlock.
+		tags := []string{alias.Alias, alias.Tag}
+		slices.Sort(tags)
+
+		for _, tag := range tags {
+			if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('tag:' || $1))`, tag); err != nil {
+				return err
+			}
+		}
+
+		var isAlias bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tag_aliases WHERE alias = $1)`, alias.Tag).Scan(&isAlias)
+		if err != nil {
+			return err
+		}
+
+		if isAlias {
+			return ErrConflict
+		}
+
+		query := `
+			INSERT INTO tag_aliases (alias, tag, created_by)
+			VALUES ($1, $2, $3)
+			RETURNING created_at
+		`
+		err = tx.QueryRowContext(ctx, query, alias.Alias, alias.Tag, alias.CreatedBy).Scan(&alias.CreatedAt)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				return ErrConflict
+			}
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE tag_aliases SET tag = $2 WHERE tag = $1`, alias.Alias, alias.Tag)
+		if err != nil {
+			return err
+		}
+
+		// Followers of the alias follow the tag instead.
+		query = `
+			INSERT INTO tag_follows (user_id, tag, created_at)
+			SELECT user_id, $2, created_at FROM tag_follows WHERE tag = $1
+			ON CONFLICT (user_id, tag) DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, query, alias.Alias, alias.Tag); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM tag_follows WHERE tag = $1`, alias.Alias); err != nil {
+			return err
+		}
+
+		// Retagging isn't an edit of the post, so the version, and with it
+		// the ETag, stays as it is.
+		query = `UPDATE posts SET tags = ` + canonicalTags("tags") + ` WHERE tags @> ARRAY[$1]::varchar[]`
+		_, err = tx.ExecContext(ctx, query, alias.Alias)
+
+		return err
+	})
+}
+
+// DeleteAlias stops merging alias into its tag. Posts retagged when the
+// alias was created keep the tag.
+func (s *TagsStore) DeleteAlias(ctx context.Context, alias string) error {
+	query := `DELETE FROM tag_aliases WHERE alias = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, alias)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Follow adds tag, or the tag it is an alias of, to the tags followed by
+// userId. Following a tag twice is a no-op.
+func (s *TagsStore) Follow(ctx context.Context, userId int64, tag string) error {
+	query := `
+		INSERT INTO tag_follows (user_id, tag)
+		SELECT $1, COALESCE((SELECT a.tag FROM tag_aliases a WHERE a.alias = $2), $2)
+		ON CONFLICT (user_id, tag) DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userId, tag)
+
+	return err
+}
+
+// Unfollow undoes Follow. Unfollowing a tag that isn't followed is a no-op.
+func (s *TagsStore) Unfollow(ctx context.Context, userId int64, tag string) error {
+	query := `
+		DELETE FROM tag_follows
+		WHERE user_id = $1 AND tag = COALESCE((SELECT a.tag FROM tag_aliases a WHERE a.alias = $2), $2)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userId, tag)
+
+	return err
+}
+
+// GetFollowed returns the tags followed by userId in alphabetical order.
+func (s *TagsStore) GetFollowed(ctx context.Context, userId int64) ([]string, error) {
+	query := `SELECT tag FROM tag_follows WHERE user_id = $1 ORDER BY tag`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
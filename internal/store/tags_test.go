@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	t.Run("should fold tags to their canonical form and drop duplicates", func(t *testing.T) {
+		tags, err := NormalizeTags([]string{"#Go", " golang ", "go", "Café_2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{"go", "golang", "café_2"}
+		if !slices.Equal(tags, expected) {
+			t.Errorf("expected %v; got %v", expected, tags)
+		}
+	})
+
+	t.Run("should return an empty list for no tags", func(t *testing.T) {
+		tags, err := NormalizeTags(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tags == nil || len(tags) != 0 {
+			t.Errorf("expected an empty list; got %#v", tags)
+		}
+	})
+
+	t.Run("should reject invalid tags", func(t *testing.T) {
+		for _, tag := range []string{"", "#", "two words", "c++", strings.Repeat("a", MaxTagLength+1)} {
+			if _, err := NormalizeTags([]string{tag}); !errors.Is(err, ErrInvalidTag) {
+				t.Errorf("expected ErrInvalidTag for %q; got %v", tag, err)
+			}
+		}
+	})
+}
+
+func TestCreateAlias(t *testing.T) {
+	s, _ := newTestStorage(t)
+	ctx := context.Background()
+
+	t.Run("should not let opposite aliases race into a cycle", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+
+		for i, alias := range []TagAlias{{Alias: "a", Tag: "b"}, {Alias: "b", Tag: "a"}} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = s.Tags.CreateAlias(ctx, &alias)
+			}()
+		}
+		wg.Wait()
+
+		conflicts := 0
+		for _, err := range errs {
+			switch {
+			case errors.Is(err, ErrConflict):
+				conflicts++
+			case err != nil:
+				t.Fatal(err)
+			}
+		}
+
+		if conflicts != 1 {
+			t.Errorf("expected exactly one conflict; got %v", errs)
+		}
+
+		aliases, err := s.Tags.GetAliases(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(aliases) != 1 {
+			t.Errorf("expected a single alias; got %+v", aliases)
+		}
+	})
+
+	t.Run("should repoint aliases of the alias", func(t *testing.T) {
+		if err := s.Tags.CreateAlias(ctx, &TagAlias{Alias: "golang", Tag: "go"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Tags.CreateAlias(ctx, &TagAlias{Alias: "go", Tag: "gopher"}); err != nil {
+			t.Fatal(err)
+		}
+
+		aliases, err := s.Tags.GetAliases(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, alias := range aliases {
+			if alias.Tag == "go" {
+				t.Errorf("expected %s to point at gopher; got go", alias.Alias)
+			}
+		}
+	})
+}
@@ -0,0 +1,235 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// maxThreadNodes bounds the size of a thread response. Levels beyond it are
+// left out, to be fetched node by node.
+const maxThreadNodes = 500
+
+// ThreadNode is a post of a conversation with its replies, oldest first.
+type ThreadNode struct {
+	ID int64 `json:"id"`
+	// Post is nil on tombstones: posts that are deleted or hidden from the
+	// viewer, but are kept in the tree because they have replies.
+	Post         *PostForFeed  `json:"post,omitempty"`
+	Tombstone    bool          `json:"tombstone"`
+	RepliesCount int           `json:"replies_count"`
+	Replies      []*ThreadNode `json:"replies"`
+	// NextCursor continues Replies when they were cut at the limit.
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	// cursor is the position of the node among its siblings.
+	cursor Cursor
+}
+
+type ThreadQuery struct {
+	Depth  int     `json:"depth" validate:"gte=1,lte=5"`
+	Limit  int     `json:"limit" validate:"gte=1,lte=20"`
+	Cursor *Cursor `json:"-"`
+}
+
+func (tq *ThreadQuery) Parse(r *http.Request) error {
+	rq := r.URL.Query()
+
+	depth := rq.Get("depth")
+	if depth != "" {
+		d, err := strconv.Atoi(depth)
+		if err != nil {
+			return err
+		}
+		tq.Depth = d
+	}
+
+	limit := rq.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return err
+		}
+		tq.Limit = l
+	}
+
+	cursor := rq.Get("cursor")
+	if cursor != "" {
+		c, err := DecodeCursor(cursor)
+		if err != nil {
+			return err
+		}
+		tq.Cursor = c
+	}
+
+	return nil
+}
+
+// repliesCount returns an SQL expression counting the replies of the post
+// aliased as post.
+func repliesCount(post string) string {
+	return fmt.Sprintf(`(
+		SELECT COUNT(*) FROM posts rc
+		WHERE rc.reply_to_post_id = %[1]s.id AND rc.deleted_at IS NULL AND rc.status = 'published'
+	)`, post)
+}
+
+type threadRow struct {
+	node     *ThreadNode
+	parentID int64
+	visible  bool
+}
+
+// GetThread returns the conversation below postId as seen by viewerId, down
+// to tq.Depth levels of replies with at most tq.Limit replies per post. The
+// cursor applies to the replies of postId. postId may be a tombstone itself,
+// but ErrNotFound is returned when there is nothing to show at all.
+func (s *PostsStore) GetThread(ctx context.Context, postId, viewerId int64, tq ThreadQuery) (*ThreadNode, error) {
+	query := `
+		SELECT p.id, ` + visibleTo("p", "$2") + `, ` + repliesCount("p") + `
+		FROM posts p
+		WHERE p.id = $1 AND p.status = 'published'
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	root := &ThreadNode{}
+	var visible bool
+
+	err := s.db.QueryRowContext(ctx, query, postId, viewerId).Scan(&root.ID, &visible, &root.RepliesCount)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if !visible && root.RepliesCount == 0 {
+		return nil, ErrNotFound
+	}
+
+	root.Replies = []*ThreadNode{}
+
+	var visibleIDs []int64
+	if visible {
+		visibleIDs = append(visibleIDs, root.ID)
+	}
+
+	nodes := map[int64]*ThreadNode{root.ID: root}
+	parents := []*ThreadNode{root}
+	cursor := tq.Cursor
+
+	for depth := 0; depth < tq.Depth && len(parents) > 0 && len(nodes) < maxThreadNodes; depth++ {
+		rows, err := s.getReplies(ctx, parents, viewerId, cursor, tq.Limit+1)
+		if err != nil {
+			return nil, err
+		}
+		cursor = nil
+
+		parents = nil
+		for _, row := range rows {
+			parent := nodes[row.parentID]
+
+			if len(parent.Replies) == tq.Limit {
+				parent.NextCursor = parent.Replies[tq.Limit-1].cursor.Encode()
+				continue
+			}
+
+			row.node.Replies = []*ThreadNode{}
+			parent.Replies = append(parent.Replies, row.node)
+			nodes[row.node.ID] = row.node
+
+			if row.visible {
+				visibleIDs = append(visibleIDs, row.node.ID)
+			}
+
+			// Tombstones are only listed when they have replies, which
+			// may all be tombstones too.
+			if row.node.RepliesCount > 0 || !row.visible {
+				parents = append(parents, row.node)
+			}
+		}
+	}
+
+	posts, err := s.GetByIds(ctx, viewerId, visibleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+		nodes[post.ID].Post = post
+	}
+
+	// Posts that could not be loaded, including those deleted since the
+	// tree was read, are tombstones.
+	for _, node := range nodes {
+		if node.Post == nil {
+			node.Tombstone = true
+		}
+	}
+
+	return root, nil
+}
+
+func (s *PostsStore) getReplies(ctx context.Context, parents []*ThreadNode, viewerId int64, cursor *Cursor, limit int) ([]threadRow, error) {
+	query := `
+		SELECT id, parent_id, visible, replies_count, created_at FROM (
+			SELECT
+				p.id, p.reply_to_post_id AS parent_id, p.created_at,
+				` + visibleTo("p", "$2") + ` AS visible,
+				` + repliesCount("p") + ` AS replies_count,
+				ROW_NUMBER() OVER (PARTITION BY p.reply_to_post_id ORDER BY p.created_at, p.id) AS n
+			FROM posts p
+			WHERE
+				p.reply_to_post_id = ANY($1) AND
+				p.status = 'published' AND
+				($3::timestamptz IS NULL OR (p.created_at, p.id) > ($3, $4)) AND
+				(` + visibleTo("p", "$2") + ` OR EXISTS (
+					SELECT 1 FROM posts c WHERE c.reply_to_post_id = p.id AND c.status = 'published'
+				))
+		) replies
+		WHERE n <= $5
+		ORDER BY parent_id, n
+	`
+
+	ids := make([]int64, len(parents))
+	for i, parent := range parents {
+		ids[i] = parent.ID
+	}
+
+	var cursorCreatedAt any
+	var cursorID int64
+	if cursor != nil {
+		cursorCreatedAt = cursor.CreatedAt
+		cursorID = cursor.ID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids), viewerId, cursorCreatedAt, cursorID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var replies []threadRow
+	for rows.Next() {
+		row := threadRow{node: &ThreadNode{}}
+		if err := rows.Scan(&row.node.ID, &row.parentID, &row.visible, &row.node.RepliesCount, &row.node.cursor.CreatedAt); err != nil {
+			return nil, err
+		}
+		row.node.cursor.ID = row.node.ID
+		replies = append(replies, row)
+	}
+
+	return replies, rows.Err()
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestGetThread(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	viewer := createTestUser(t, db, "viewer", "")
+
+	root := createTestPost(t, s, &Post{UserID: author.ID})
+
+	reply := func(parent *Post) *Post {
+		return createTestPost(t, s, &Post{UserID: author.ID, ReplyToPostID: &parent.ID, ThreadID: &root.ID})
+	}
+
+	a := reply(root)
+	b := reply(root)
+	c := reply(root)
+	a1 := reply(a)
+	a1a := reply(a1)
+
+	thread := func(t *testing.T, tq ThreadQuery) *ThreadNode {
+		t.Helper()
+
+		node, err := s.Posts.GetThread(ctx, root.ID, viewer.ID, tq)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return node
+	}
+
+	ids := func(nodes []*ThreadNode) []int64 {
+		var ids []int64
+		for _, node := range nodes {
+			ids = append(ids, node.ID)
+		}
+		return ids
+	}
+
+	t.Run("should order replies oldest first", func(t *testing.T) {
+		node := thread(t, ThreadQuery{Depth: 3, Limit: 10})
+
+		if got, expected := ids(node.Replies), []int64{a.ID, b.ID, c.ID}; !slices.Equal(got, expected) {
+			t.Fatalf("expected %v; got %v", expected, got)
+		}
+		if node.RepliesCount != 3 {
+			t.Errorf("expected 3 replies; got %d", node.RepliesCount)
+		}
+
+		nested := node.Replies[0]
+		if len(nested.Replies) != 1 || nested.Replies[0].ID != a1.ID {
+			t.Fatalf("expected %d to reply to %d; got %v", a1.ID, a.ID, ids(nested.Replies))
+		}
+		if got := ids(nested.Replies[0].Replies); !slices.Equal(got, []int64{a1a.ID}) {
+			t.Errorf("expected %d to reply to %d; got %v", a1a.ID, a1.ID, got)
+		}
+	})
+
+	t.Run("should stop at the depth", func(t *testing.T) {
+		node := thread(t, ThreadQuery{Depth: 1, Limit: 10})
+
+		for _, reply := range node.Replies {
+			if len(reply.Replies) != 0 {
+				t.Errorf("expected no replies below depth 1; got %v under %d", ids(reply.Replies), reply.ID)
+			}
+		}
+
+		if node.Replies[0].RepliesCount != 1 {
+			t.Errorf("expected the count of the replies left out; got %d", node.Replies[0].RepliesCount)
+		}
+	})
+
+	t.Run("should page through siblings", func(t *testing.T) {
+		node := thread(t, ThreadQuery{Depth: 1, Limit: 2})
+
+		if got, expected := ids(node.Replies), []int64{a.ID, b.ID}; !slices.Equal(got, expected) {
+			t.Fatalf("expected %v; got %v", expected, got)
+		}
+		if node.NextCursor == "" {
+			t.Fatal("expected a cursor to the next replies")
+		}
+
+		cursor, err := DecodeCursor(node.NextCursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		node = thread(t, ThreadQuery{Depth: 1, Limit: 2, Cursor: cursor})
+
+		if got, expected := ids(node.Replies), []int64{c.ID}; !slices.Equal(got, expected) {
+			t.Errorf("expected %v; got %v", expected, got)
+		}
+		if node.NextCursor != "" {
+			t.Errorf("expected no more replies; got cursor %s", node.NextCursor)
+		}
+	})
+
+	t.Run("should leave tombstones of deleted posts with replies", func(t *testing.T) {
+		for _, post := range []*Post{a, c} {
+			if err := s.Posts.DeleteById(ctx, post.ID, post.Version, author.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		node := thread(t, ThreadQuery{Depth: 3, Limit: 10})
+
+		if got, expected := ids(node.Replies), []int64{a.ID, b.ID}; !slices.Equal(got, expected) {
+			t.Fatalf("expected %v; got %v", expected, got)
+		}
+
+		tombstone := node.Replies[0]
+		if !tombstone.Tombstone || tombstone.Post != nil {
+			t.Errorf("expected %d to be a tombstone; got %+v", a.ID, tombstone)
+		}
+		if len(tombstone.Replies) != 1 || tombstone.Replies[0].Post == nil {
+			t.Errorf("expected the replies of the tombstone to be kept; got %v", ids(tombstone.Replies))
+		}
+
+		if sibling := node.Replies[1]; sibling.Tombstone || sibling.Post == nil || sibling.Post.ID != b.ID {
+			t.Errorf("expected %d to be loaded; got %+v", b.ID, sibling)
+		}
+	})
+
+	t.Run("should not find deleted posts without replies", func(t *testing.T) {
+		if _, err := s.Posts.GetThread(ctx, c.ID, viewer.ID, ThreadQuery{Depth: 1, Limit: 10}); err != ErrNotFound {
+			t.Errorf("expected ErrNotFound; got %v", err)
+		}
+	})
+}
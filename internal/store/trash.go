@@ -0,0 +1,309 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type TrashedPost struct {
+	ID        int64  `json:"id"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	UserID    int64  `json:"user_id"`
+	CreatedAt string `json:"created_at"`
+	DeletedAt string `json:"deleted_at"`
+}
+
+type TrashedComment struct {
+	ID        int64  `json:"id"`
+	PostID    int64  `json:"post_id"`
+	Content   string `json:"content"`
+	UserID    int64  `json:"user_id"`
+	CreatedAt string `json:"created_at"`
+	DeletedAt string `json:"deleted_at"`
+}
+
+// Trash is a page of the posts and comments of a user that were deleted
+// and can still be restored, most recently deleted first. NextCursor is
+// empty on the last page.
+type Trash struct {
+	Posts      []TrashedPost    `json:"posts"`
+	Comments   []TrashedComment `json:"comments"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// Kinds of rows in the trash, as found in the Kind of its cursors.
+const (
+	trashPost    = "post"
+	trashComment = "comment"
+)
+
+// TrashQuery selects a page of the trash.
+type TrashQuery struct {
+	Limit  int     `json:"limit" validate:"gte=1,lte=50"`
+	Cursor *Cursor `json:"-"`
+}
+
+func (tq *TrashQuery) Parse(r *http.Request) error {
+	rq := r.URL.Query()
+
+	limit := rq.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return err
+		}
+		tq.Limit = l
+	}
+
+	cursor := rq.Get("cursor")
+	if cursor != "" {
+		c, err := DecodeCursor(cursor)
+		if err != nil {
+			return err
+		}
+
+		if c.Kind != trashPost && c.Kind != trashComment {
+			return ErrInvalidCursor
+		}
+
+		tq.Cursor = c
+	}
+
+	return nil
+}
+
+type TrashStore struct {
+	db *sql.DB
+}
+
+func NewTrashStore(db *sql.DB) *TrashStore {
+	return &TrashStore{db: db}
+}
+
+// Get returns a page of the posts and comments of userId deleted after
+// since, whoever deleted them.
+func (s *TrashStore) Get(ctx context.Context, userId int64, since time.Time, tq TrashQuery) (*Trash, error) {
+	// Posts and comments are ordered together by deletion time, then kind
+	// and id. The cursor bound is applied in each branch so that both can
+	// use their indexes.
+	query := `
+		SELECT kind, id, post_id, title, content, user_id, created_at, deleted_at
+		FROM (
+			SELECT 'post' AS kind, p.id, p.id AS post_id, p.title, p.content, p.user_id, p.created_at, p.deleted_at
+			FROM posts p
+			WHERE
+				p.user_id = $1 AND p.deleted_at > $2 AND
+				($3::timestamptz IS NULL OR (p.deleted_at, 'post', p.id) < ($3, $4::text, $5))
+			UNION ALL
+			SELECT 'comment', c.id, c.post_id, '', c.content, c.user_id, c.created_at, c.deleted_at
+			FROM comments c
+			WHERE
+				c.user_id = $1 AND c.deleted_at > $2 AND
+				($3::timestamptz IS NULL OR (c.deleted_at, 'comment', c.id) < ($3, $4::text, $5))
+		) items
+		ORDER BY deleted_at DESC, kind DESC, id DESC
+		LIMIT $6
+	`
+
+	var cursorDeletedAt any
+	var cursorKind string
+	var cursorID int64
+	if tq.Cursor != nil {
+		cursorDeletedAt = tq.Cursor.CreatedAt
+		cursorKind = tq.Cursor.Kind
+		cursorID = tq.Cursor.ID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userId, since, cursorDeletedAt, cursorKind, cursorID, tq.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trash := &Trash{
+		Posts:    []TrashedPost{},
+		Comments: []TrashedComment{},
+	}
+
+	var last Cursor
+
+	for rows.Next() {
+		// The row after the page only tells that there is another one.
+		if len(trash.Posts)+len(trash.Comments) == tq.Limit {
+			trash.NextCursor = last.Encode()
+			break
+		}
+
+		var kind string
+		var postId int64
+		p := TrashedPost{}
+
+		if err := rows.Scan(&kind, &p.ID, &postId, &p.Title, &p.Content, &p.UserID, &p.CreatedAt, &p.DeletedAt); err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case trashPost:
+			trash.Posts = append(trash.Posts, p)
+		default:
+			trash.Comments = append(trash.Comments, TrashedComment{
+				ID:        p.ID,
+				PostID:    postId,
+				Content:   p.Content,
+				UserID:    p.UserID,
+				CreatedAt: p.CreatedAt,
+				DeletedAt: p.DeletedAt,
+			})
+		}
+
+		last = Cursor{CreatedAt: p.DeletedAt, ID: p.ID, Kind: kind}
+	}
+
+	return trash, rows.Err()
+}
+
+// RestorePost takes a post deleted after since out of the trash. Only its
+// author may restore it, unless anyone is set.
+func (s *TrashStore) RestorePost(ctx context.Context, postId, userId int64, since time.Time, anyone bool) error {
+	query := `
+		UPDATE posts
+		SET deleted_at = NULL, deleted_by = NULL
+		WHERE id = $1 AND deleted_at > $2 AND (user_id = $3 OR $4)
+	`
+
+	return s.restore(ctx, query, postId, since, userId, anyone)
+}
+
+// RestoreComment takes a comment deleted after since out of the trash. Only
+// its author may restore it, unless anyone is set.
+func (s *TrashStore) RestoreComment(ctx context.Context, commentId, userId int64, since time.Time, anyone bool) error {
+	query := `
+		UPDATE comments
+		SET deleted_at = NULL, deleted_by = NULL
+		WHERE id = $1 AND deleted_at > $2 AND (user_id = $3 OR $4)
+	`
+
+	return s.restore(ctx, query, commentId, since, userId, anyone)
+}
+
+func (s *TrashStore) restore(ctx context.Context, query string, args ...any) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Purge permanently deletes up to limit posts and limit comments that were
+// deleted before before, and returns how many rows it removed. The comments
+// of a purged post go with it; its reactions, bookmarks, reposts, mentions
+// and revisions are removed by their foreign keys. Posts that have replies
+// stay behind as tombstones of their threads, with their content, comments
+// and revisions removed. Such posts have an empty title, which posts can't
+// otherwise have. Comments that have replies likewise stay behind with
+// their content removed.
+func (s *TrashStore) Purge(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var purged int64
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		postsQuery := `
+			WITH expired AS (
+				SELECT id FROM posts p
+				WHERE deleted_at < $1 AND NOT EXISTS (
+					SELECT 1 FROM posts r WHERE r.reply_to_post_id = p.id
+				)
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			), purged_comments AS (
+				DELETE FROM comments WHERE post_id IN (SELECT id FROM expired)
+			)
+			DELETE FROM posts WHERE id IN (SELECT id FROM expired)
+		`
+
+		tombstonesQuery := `
+			WITH expired AS (
+				SELECT id FROM posts p
+				WHERE deleted_at < $1 AND title <> '' AND EXISTS (
+					SELECT 1 FROM posts r WHERE r.reply_to_post_id = p.id
+				)
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			), purged_comments AS (
+				DELETE FROM comments WHERE post_id IN (SELECT id FROM expired)
+			), purged_revisions AS (
+				DELETE FROM post_revisions WHERE post_id IN (SELECT id FROM expired)
+			)
+			UPDATE posts SET title = '', content = '', tags = '{}', entities = '[]'
+			WHERE id IN (SELECT id FROM expired)
+		`
+
+		commentsQuery := `
+			DELETE FROM comments
+			WHERE id IN (
+				SELECT id FROM comments c
+				WHERE deleted_at < $1 AND NOT EXISTS (
+					SELECT 1 FROM comments r WHERE r.parent_comment_id = c.id
+				)
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+		`
+
+		// Comments with replies are kept as tombstones until their replies
+		// are gone.
+		commentTombstonesQuery := `
+			WITH expired AS (
+				SELECT id FROM comments c
+				WHERE deleted_at < $1 AND (content <> '' OR entities <> '[]') AND EXISTS (
+					SELECT 1 FROM comments r WHERE r.parent_comment_id = c.id
+				)
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			), purged_mentions AS (
+				DELETE FROM comment_mentions WHERE comment_id IN (SELECT id FROM expired)
+			)
+			UPDATE comments SET content = '', entities = '[]'
+			WHERE id IN (SELECT id FROM expired)
+		`
+
+		for _, query := range []string{postsQuery, tombstonesQuery, commentsQuery, commentTombstonesQuery} {
+			ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+			res, err := tx.ExecContext(ctx, query, before, limit)
+			cancel()
+			if err != nil {
+				return err
+			}
+
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			purged += rows
+		}
+
+		return nil
+	})
+
+	return purged, err
+}
@@ -0,0 +1,302 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrashGet(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	moderator := createTestUser(t, db, "moderator", "moderator")
+
+	post := createTestPost(t, s, &Post{UserID: author.ID})
+	deletedPost := createTestPost(t, s, &Post{UserID: author.ID})
+	moderatedPost := createTestPost(t, s, &Post{UserID: author.ID})
+	comment := createTestComment(t, s, &Comment{PostId: post.ID, UserId: author.ID})
+	expiredComment := createTestComment(t, s, &Comment{PostId: post.ID, UserId: author.ID})
+
+	if err := s.Posts.DeleteById(ctx, deletedPost.ID, deletedPost.Version, author.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Posts.DeleteById(ctx, moderatedPost.ID, moderatedPost.Version, moderator.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int64{comment.ID, expiredComment.ID} {
+		if err := s.Comments.Delete(ctx, id, author.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Deletion times are spread out so that the order is known.
+	execTest(t, db, `UPDATE posts SET deleted_at = NOW() - interval '1 hour' WHERE id = $1`, deletedPost.ID)
+	execTest(t, db, `UPDATE comments SET deleted_at = NOW() - interval '2 hours' WHERE id = $1`, comment.ID)
+	execTest(t, db, `UPDATE comments SET deleted_at = NOW() - interval '3 days' WHERE id = $1`, expiredComment.ID)
+
+	since := time.Now().Add(-24 * time.Hour)
+
+	t.Run("should list what was deleted from the author, whoever deleted it", func(t *testing.T) {
+		trash, err := s.Trash.Get(ctx, author.ID, since, TrashQuery{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var posts, comments []int64
+		for _, p := range trash.Posts {
+			posts = append(posts, p.ID)
+		}
+		for _, c := range trash.Comments {
+			comments = append(comments, c.ID)
+		}
+
+		if expected := []int64{moderatedPost.ID, deletedPost.ID}; !slices.Equal(posts, expected) {
+			t.Errorf("expected posts %v; got %v", expected, posts)
+		}
+		if expected := []int64{comment.ID}; !slices.Equal(comments, expected) {
+			t.Errorf("expected comments %v; got %v", expected, comments)
+		}
+		if trash.NextCursor != "" {
+			t.Errorf("expected no next page; got %s", trash.NextCursor)
+		}
+	})
+
+	t.Run("should leave out what others deleted from others", func(t *testing.T) {
+		trash, err := s.Trash.Get(ctx, moderator.ID, since, TrashQuery{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(trash.Posts) != 0 || len(trash.Comments) != 0 {
+			t.Errorf("expected an empty trash; got %+v", trash)
+		}
+	})
+
+	t.Run("should page through posts and comments together", func(t *testing.T) {
+		var got []string
+
+		tq := TrashQuery{Limit: 1}
+		for page := 0; page < 5; page++ {
+			trash, err := s.Trash.Get(ctx, author.ID, since, tq)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if n := len(trash.Posts) + len(trash.Comments); n != 1 {
+				t.Fatalf("expected 1 item on page %d; got %d", page, n)
+			}
+			for _, p := range trash.Posts {
+				got = append(got, "post "+p.DeletedAt)
+			}
+			for _, c := range trash.Comments {
+				got = append(got, "comment "+c.DeletedAt)
+			}
+
+			if trash.NextCursor == "" {
+				break
+			}
+
+			cursor, err := DecodeCursor(trash.NextCursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tq.Cursor = cursor
+		}
+
+		if len(got) != 3 {
+			t.Fatalf("expected 3 items over all pages; got %v", got)
+		}
+		if !strings.HasPrefix(got[2], "comment") {
+			t.Errorf("expected the comment deleted first to come last; got %v", got)
+		}
+	})
+}
+
+func TestTrashRestore(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	moderator := createTestUser(t, db, "moderator", "moderator")
+	stranger := createTestUser(t, db, "stranger", "")
+
+	post := createTestPost(t, s, &Post{UserID: author.ID})
+	comment := createTestComment(t, s, &Comment{PostId: post.ID, UserId: author.ID})
+
+	if err := s.Comments.Delete(ctx, comment.ID, moderator.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Posts.DeleteById(ctx, post.ID, post.Version, moderator.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+
+	if err := s.Trash.RestorePost(ctx, post.ID, stranger.ID, since, false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a stranger not to restore the post; got %v", err)
+	}
+	if err := s.Trash.RestoreComment(ctx, comment.ID, moderator.ID, since, false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the deleting moderator not to restore the comment; got %v", err)
+	}
+	if err := s.Trash.RestorePost(ctx, post.ID, author.ID, time.Now().Add(time.Minute), false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the post not to be restored past the retention window; got %v", err)
+	}
+
+	if err := s.Trash.RestorePost(ctx, post.ID, author.ID, since, false); err != nil {
+		t.Errorf("expected the author to restore the post; got %v", err)
+	}
+	if err := s.Trash.RestoreComment(ctx, comment.ID, stranger.ID, since, true); err != nil {
+		t.Errorf("expected an admin to restore the comment; got %v", err)
+	}
+
+	if _, err := s.Posts.GetById(ctx, post.ID); err != nil {
+		t.Errorf("expected the post to be back; got %v", err)
+	}
+	if _, err := s.Comments.GetById(ctx, comment.ID); err != nil {
+		t.Errorf("expected the comment to be back; got %v", err)
+	}
+}
+
+func TestTrashPurge(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	reader := createTestUser(t, db, "reader", "")
+
+	// A post that goes for good, with its comments and reactions.
+	purged := createTestPost(t, s, &Post{UserID: author.ID})
+	purgedComment := createTestComment(t, s, &Comment{PostId: purged.ID, UserId: reader.ID})
+	if err := s.Reactions.Set(ctx, purged.ID, reader.ID, "like"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A post with a reply, which stays as a tombstone of its thread.
+	tombstone := createTestPost(t, s, &Post{UserID: author.ID})
+	createTestComment(t, s, &Comment{PostId: tombstone.ID, UserId: reader.ID})
+	reply := createTestPost(t, s, &Post{UserID: reader.ID, ReplyToPostID: &tombstone.ID, ThreadID: &tombstone.ID})
+
+	// Comments on a live post: one with a reply, which stays as a
+	// tombstone, and one without.
+	post := createTestPost(t, s, &Post{UserID: author.ID})
+	parent := createTestComment(t, s, &Comment{PostId: post.ID, UserId: author.ID})
+	child := createTestComment(t, s, &Comment{PostId: post.ID, UserId: reader.ID, ParentCommentID: &parent.ID, Depth: 1})
+	lone := createTestComment(t, s, &Comment{PostId: post.ID, UserId: author.ID})
+
+	// A post deleted recently, which is kept.
+	recent := createTestPost(t, s, &Post{UserID: author.ID})
+
+	for _, p := range []*Post{purged, tombstone, recent} {
+		if err := s.Posts.DeleteById(ctx, p.ID, p.Version, author.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []*Comment{parent, lone} {
+		if err := s.Comments.Delete(ctx, c.ID, author.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	execTest(t, db, `UPDATE posts SET deleted_at = NOW() - interval '31 days' WHERE id IN ($1, $2)`, purged.ID, tombstone.ID)
+	execTest(t, db, `UPDATE comments SET deleted_at = NOW() - interval '31 days' WHERE id IN ($1, $2)`, parent.ID, lone.ID)
+
+	if _, err := s.Trash.Purge(ctx, time.Now().Add(-30*24*time.Hour), 100); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := []struct {
+		name     string
+		query    string
+		arg      int64
+		expected int
+	}{
+		{"purged post", `SELECT COUNT(*) FROM posts WHERE id = $1`, purged.ID, 0},
+		{"comments of the purged post", `SELECT COUNT(*) FROM comments WHERE post_id = $1`, purged.ID, 0},
+		{"comment of the purged post", `SELECT COUNT(*) FROM comments WHERE id = $1`, purgedComment.ID, 0},
+		{"reactions to the purged post", `SELECT COUNT(*) FROM post_reactions WHERE post_id = $1`, purged.ID, 0},
+		{"revisions of the purged post", `SELECT COUNT(*) FROM post_revisions WHERE post_id = $1`, purged.ID, 0},
+		{"tombstone", `SELECT COUNT(*) FROM posts WHERE id = $1 AND title = '' AND content = ''`, tombstone.ID, 1},
+		{"comments of the tombstone", `SELECT COUNT(*) FROM comments WHERE post_id = $1`, tombstone.ID, 0},
+		{"revisions of the tombstone", `SELECT COUNT(*) FROM post_revisions WHERE post_id = $1`, tombstone.ID, 0},
+		{"reply to the tombstone", `SELECT COUNT(*) FROM posts WHERE id = $1 AND deleted_at IS NULL`, reply.ID, 1},
+		{"comment tombstone", `SELECT COUNT(*) FROM comments WHERE id = $1 AND content = ''`, parent.ID, 1},
+		{"reply to the comment tombstone", `SELECT COUNT(*) FROM comments WHERE id = $1 AND content <> ''`, child.ID, 1},
+		{"comment without replies", `SELECT COUNT(*) FROM comments WHERE id = $1`, lone.ID, 0},
+		{"recently deleted post", `SELECT COUNT(*) FROM posts WHERE id = $1`, recent.ID, 1},
+	}
+
+	for _, c := range counts {
+		if n := countTest(t, db, c.query, c.arg); n != c.expected {
+			t.Errorf("%s: expected %d rows; got %d", c.name, c.expected, n)
+		}
+	}
+
+	purgedAgain, err := s.Trash.Purge(ctx, time.Now().Add(-30*24*time.Hour), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purgedAgain != 0 {
+		t.Errorf("expected tombstones to be left alone; purged %d rows", purgedAgain)
+	}
+}
+
+func TestSoftDeletedReads(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	reader := createTestUser(t, db, "reader", "")
+
+	follow(t, s, reader.ID, author.ID)
+
+	kept := createTestPost(t, s, &Post{UserID: author.ID})
+	deleted := createTestPost(t, s, &Post{UserID: author.ID})
+	keptComment := createTestComment(t, s, &Comment{PostId: kept.ID, UserId: reader.ID})
+	deletedComment := createTestComment(t, s, &Comment{PostId: kept.ID, UserId: reader.ID})
+
+	if err := s.Posts.DeleteById(ctx, deleted.ID, deleted.Version, author.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Comments.Delete(ctx, deletedComment.ID, reader.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Posts.GetById(ctx, deleted.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the deleted post to be missing; got %v", err)
+	}
+	if _, err := s.Comments.GetById(ctx, deletedComment.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the deleted comment to be missing; got %v", err)
+	}
+
+	byUser, err := s.Posts.GetByUser(ctx, author.ID, author.ID, PaginatedPostsQuery{Limit: 10, Tags: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := postIDs(byUser.Posts); !slices.Equal(got, []int64{kept.ID}) {
+		t.Errorf("expected the posts of the author to be %v; got %v", []int64{kept.ID}, got)
+	}
+
+	feed, err := s.Posts.GetUserFeed(ctx, reader.ID, PaginatedFeedQuery{Limit: 10, Sort: "desc", Tags: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := postIDs(feed.Posts); !slices.Equal(got, []int64{kept.ID}) {
+		t.Errorf("expected the feed to be %v; got %v", []int64{kept.ID}, got)
+	}
+	if feed.Posts[0].CommentsCount != 1 {
+		t.Errorf("expected the deleted comment not to be counted; got %d comments", feed.Posts[0].CommentsCount)
+	}
+
+	comments, err := s.Comments.GetByPostId(ctx, kept.ID, reader.ID, CommentsQuery{Limit: 10, Mode: CommentsFlat, Replies: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments.Comments) != 1 || comments.Comments[0].ID != keptComment.ID || comments.TotalCount != 1 {
+		t.Errorf("expected only comment %d; got %d comments of %d", keptComment.ID, len(comments.Comments), comments.TotalCount)
+	}
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TrendingPost and TrendingTag are entries of the trending lists, highest
+// score first. See migration 000023 for how scores are computed.
+type TrendingPost struct {
+	PostID int64   `json:"post_id"`
+	Score  float64 `json:"score"`
+}
+
+type TrendingTag struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+}
+
+type TrendingStore struct {
+	db *sql.DB
+}
+
+func NewTrendingStore(db *sql.DB) *TrendingStore {
+	return &TrendingStore{db: db}
+}
+
+// ComputePosts scores the trending posts from live data, for callers that
+// keep the list elsewhere than in the trending_posts materialized view.
+func (s *TrendingStore) ComputePosts(ctx context.Context, limit int) ([]TrendingPost, error) {
+	return s.getPosts(ctx, `
+		SELECT post_id, score FROM trending_post_scores
+		WHERE author_rank <= 2
+		ORDER BY score DESC, post_id DESC
+		LIMIT $1
+	`, limit)
+}
+
+// ComputeTags is the ComputePosts of tags.
+func (s *TrendingStore) ComputeTags(ctx context.Context, limit int) ([]TrendingTag, error) {
+	return s.getTags(ctx, `
+		SELECT tag, score FROM trending_tag_scores
+		ORDER BY score DESC, tag
+		LIMIT $1
+	`, limit)
+}
+
+// GetPosts reads the trending posts as of the last Refresh.
+func (s *TrendingStore) GetPosts(ctx context.Context, limit int) ([]TrendingPost, error) {
+	return s.getPosts(ctx, `
+		SELECT post_id, score FROM trending_posts
+		ORDER BY score DESC, post_id DESC
+		LIMIT $1
+	`, limit)
+}
+
+// GetTags reads the trending tags as of the last Refresh.
+func (s *TrendingStore) GetTags(ctx context.Context, limit int) ([]TrendingTag, error) {
+	return s.getTags(ctx, `
+		SELECT tag, score FROM trending_tags
+		ORDER BY score DESC, tag
+		LIMIT $1
+	`, limit)
+}
+
+// Refresh recomputes the trending materialized views. Reads are not blocked
+// while it runs.
+func (s *TrendingStore) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	for _, view := range []string{"trending_posts", "trending_tags"} {
+		if _, err := s.db.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY `+view); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *TrendingStore) getPosts(ctx context.Context, query string, limit int) ([]TrendingPost, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []TrendingPost{}
+	for rows.Next() {
+		var p TrendingPost
+		if err := rows.Scan(&p.PostID, &p.Score); err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+
+	return posts, rows.Err()
+}
+
+func (s *TrendingStore) getTags(ctx context.Context, query string, limit int) ([]TrendingTag, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []TrendingTag{}
+	for rows.Next() {
+		var t TrendingTag
+		if err := rows.Scan(&t.Tag, &t.Score); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
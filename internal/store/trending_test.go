@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestTrendingSpamProtection(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	spammer := createTestUser(t, db, "spammer", "")
+	author := createTestUser(t, db, "author", "")
+	fans := []*User{
+		createTestUser(t, db, "fan1", ""),
+		createTestUser(t, db, "fan2", ""),
+		createTestUser(t, db, "fan3", ""),
+	}
+
+	var spam []*Post
+	for range 4 {
+		post := createTestPost(t, s, &Post{UserID: spammer.ID, Tags: []string{"spam"}})
+		for _, fan := range fans {
+			if err := s.Reactions.Set(ctx, post.ID, fan.ID, "like"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		spam = append(spam, post)
+	}
+
+	score := func(t *testing.T, postId int64) float64 {
+		t.Helper()
+
+		var score float64
+		err := db.QueryRow(`SELECT score FROM trending_post_scores WHERE post_id = $1`, postId).Scan(&score)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return score
+	}
+
+	t.Run("should let no author have more than two trending posts", func(t *testing.T) {
+		posts, err := s.Trending.ComputePosts(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(posts) != 2 {
+			t.Errorf("expected 2 trending posts; got %+v", posts)
+		}
+	})
+
+	t.Run("should score a tag once per author", func(t *testing.T) {
+		tags, err := s.Trending.ComputeTags(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tags) != 1 || tags[0].Tag != "spam" {
+			t.Fatalf("expected the spam tag only; got %+v", tags)
+		}
+
+		best := 0.0
+		for _, post := range spam {
+			best = math.Max(best, score(t, post.ID))
+		}
+
+		if math.Abs(tags[0].Score-best) > 0.01 {
+			t.Errorf("expected the score of the best post, %f; got %f", best, tags[0].Score)
+		}
+	})
+
+	t.Run("should not count the author's own activity", func(t *testing.T) {
+		plain := createTestPost(t, s, &Post{UserID: author.ID})
+		selfPromoted := createTestPost(t, s, &Post{UserID: author.ID})
+
+		if err := s.Reactions.Set(ctx, selfPromoted.ID, author.ID, "like"); err != nil {
+			t.Fatal(err)
+		}
+		createTestComment(t, s, &Comment{PostId: selfPromoted.ID, UserId: author.ID})
+
+		if got, expected := score(t, selfPromoted.ID), score(t, plain.ID); math.Abs(got-expected) > 0.01 {
+			t.Errorf("expected %f, as without activity; got %f", expected, got)
+		}
+	})
+
+	t.Run("should count one comment per user", func(t *testing.T) {
+		once := createTestPost(t, s, &Post{UserID: author.ID})
+		flooded := createTestPost(t, s, &Post{UserID: author.ID})
+
+		createTestComment(t, s, &Comment{PostId: once.ID, UserId: fans[0].ID})
+		for range 3 {
+			createTestComment(t, s, &Comment{PostId: flooded.ID, UserId: fans[0].ID})
+		}
+
+		if got, expected := score(t, flooded.ID), score(t, once.ID); math.Abs(got-expected) > 0.01 {
+			t.Errorf("expected %f, as with a single comment; got %f", expected, got)
+		}
+	})
+
+	t.Run("should serve the refreshed lists", func(t *testing.T) {
+		if err := s.Trending.Refresh(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		posts, err := s.Trending.GetPosts(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		spammed := 0
+		for _, post := range posts {
+			for _, p := range spam {
+				if post.PostID == p.ID {
+					spammed++
+				}
+			}
+		}
+
+		if spammed != 2 {
+			t.Errorf("expected 2 posts of the spammer; got %d in %+v", spammed, posts)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package store
+
+import "fmt"
+
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityMentioned = "mentioned"
+)
+
+const (
+	CommentPolicyEveryone  = "everyone"
+	CommentPolicyFollowers = "followers"
+	CommentPolicyMentioned = "mentioned"
+	CommentPolicyOff       = "off"
+)
+
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+)
+
+// visibleTo returns a predicate that holds when the post aliased as post may
+// be seen by the user whose id is bound to the viewer placeholder. Every
+// query that lists posts must include it; PostsStore.IsVisibleTo applies the
+// same rules to a single post. Unpublished posts are left out even for their
+// author, who finds them through PostsStore.GetDrafts instead, and so are
+// posts in the trash. Posts of users who blocked the viewer are left out too.
+func visibleTo(post, viewer string) string {
+	return fmt.Sprintf(`%[1]s.deleted_at IS NULL AND %[1]s.status = 'published' AND NOT EXISTS (
+		SELECT 1 FROM user_blocks vb WHERE vb.user_id = %[1]s.user_id AND vb.blocked_id = %[2]s
+	) AND (
+		%[1]s.user_id = %[2]s OR
+		%[1]s.visibility = 'public' OR
+		(%[1]s.visibility = 'followers' AND EXISTS (
+			SELECT 1 FROM followers vf WHERE vf.user_id = %[2]s AND vf.follower_id = %[1]s.user_id
+		)) OR
+		(%[1]s.visibility = 'mentioned' AND EXISTS (
+			SELECT 1 FROM mentions vm WHERE vm.post_id = %[1]s.id AND vm.user_id = %[2]s
+		))
+	)`, post, viewer)
+}
+
+// canComment returns a predicate that holds when the user whose id is bound
+// to the commenter placeholder may comment on the post aliased as post. It
+// assumes the post is visible to them.
+func canComment(post, commenter string) string {
+	return fmt.Sprintf(`NOT %[1]s.comments_locked AND %[1]s.comment_policy <> 'off' AND (
+		%[1]s.user_id = %[2]s OR
+		%[1]s.comment_policy = 'everyone' OR
+		(%[1]s.comment_policy = 'followers' AND EXISTS (
+			SELECT 1 FROM followers cf WHERE cf.user_id = %[2]s AND cf.follower_id = %[1]s.user_id
+		)) OR
+		(%[1]s.comment_policy = 'mentioned' AND EXISTS (
+			SELECT 1 FROM mentions cm WHERE cm.post_id = %[1]s.id AND cm.user_id = %[2]s
+		))
+	)`, post, commenter)
+}
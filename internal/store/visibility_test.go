@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestVisibleTo(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	follower := createTestUser(t, db, "follower", "")
+	mentioned := createTestUser(t, db, "mentioned", "")
+	stranger := createTestUser(t, db, "stranger", "")
+
+	follow(t, s, follower.ID, author.ID)
+
+	public := createTestPost(t, s, &Post{UserID: author.ID})
+	followersOnly := createTestPost(t, s, &Post{UserID: author.ID, Visibility: VisibilityFollowers})
+	mentionedOnly := createTestPost(t, s, &Post{
+		UserID:           author.ID,
+		Visibility:       VisibilityMentioned,
+		MentionedUserIDs: []int64{mentioned.ID},
+	})
+	draft := createTestPost(t, s, &Post{UserID: author.ID, Status: StatusDraft})
+	deleted := createTestPost(t, s, &Post{UserID: author.ID})
+
+	if err := s.Posts.DeleteById(ctx, deleted.ID, deleted.Version, author.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	all := []int64{public.ID, followersOnly.ID, mentionedOnly.ID, draft.ID, deleted.ID}
+
+	tests := []struct {
+		name     string
+		viewer   *User
+		expected []int64
+	}{
+		{"author", author, []int64{public.ID, followersOnly.ID, mentionedOnly.ID}},
+		{"follower", follower, []int64{public.ID, followersOnly.ID}},
+		{"mentioned user", mentioned, []int64{public.ID, mentionedOnly.ID}},
+		{"stranger", stranger, []int64{public.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, id := range all {
+				visible, err := s.Posts.IsVisibleTo(ctx, id, tt.viewer.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if expected := slices.Contains(tt.expected, id); visible != expected {
+					t.Errorf("post %d: expected visible to be %v; got %v", id, expected, visible)
+				}
+			}
+
+			posts, err := s.Posts.GetByIds(ctx, tt.viewer.ID, all)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := postIDs(posts); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected to list %v; got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCanComment(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "author", "")
+	follower := createTestUser(t, db, "follower", "")
+	mentioned := createTestUser(t, db, "mentioned", "")
+	stranger := createTestUser(t, db, "stranger", "")
+
+	follow(t, s, follower.ID, author.ID)
+
+	newPost := func(policy string, locked bool) *Post {
+		return createTestPost(t, s, &Post{
+			UserID:           author.ID,
+			CommentPolicy:    policy,
+			CommentsLocked:   locked,
+			MentionedUserIDs: []int64{mentioned.ID},
+		})
+	}
+
+	tests := []struct {
+		name     string
+		post     *Post
+		expected []*User
+	}{
+		{"everyone", newPost(CommentPolicyEveryone, false), []*User{author, follower, mentioned, stranger}},
+		{"followers", newPost(CommentPolicyFollowers, false), []*User{author, follower}},
+		{"mentioned", newPost(CommentPolicyMentioned, false), []*User{author, mentioned}},
+		{"off", newPost(CommentPolicyOff, false), nil},
+		{"locked", newPost(CommentPolicyEveryone, true), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, user := range []*User{author, follower, mentioned, stranger} {
+				allowed, err := s.Posts.CanComment(ctx, tt.post.ID, user.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if expected := slices.Contains(tt.expected, user); allowed != expected {
+					t.Errorf("%s: expected allowed to be %v; got %v", user.Username, expected, allowed)
+				}
+			}
+		})
+	}
+
+	t.Run("should reject unknown policies", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE posts SET comment_policy = 'friends' WHERE id = $1`, tests[0].post.ID)
+		if err == nil {
+			t.Error("expected the comment policy check to fail")
+		}
+	})
+
+	t.Run("should not find missing posts", func(t *testing.T) {
+		if _, err := s.Posts.CanComment(ctx, -1, author.ID); err != ErrNotFound {
+			t.Errorf("expected ErrNotFound; got %v", err)
+		}
+	})
+}